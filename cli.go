@@ -0,0 +1,222 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/samrobinsonsauce/eztest/internal/config"
+)
+
+// Completion kinds used by command.args and command.flagValues.
+const (
+	completeFiles    = "files"
+	completeSets     = "sets"
+	completeThemes   = "themes"
+	completeShells   = "shells"
+	completeCommands = "commands"
+)
+
+// command is one node of the ezt command tree. Help output and shell
+// completions are both generated from it, so adding a command or flag here
+// is all it takes to document and complete it.
+type command struct {
+	name    string
+	summary string
+	// usage lists the arguments shown after the command path in help.
+	usage string
+	// long is extra help text printed after the generated sections.
+	long   string
+	hidden bool
+	// rawArgs passes every argument to run without parsing flags.
+	rawArgs bool
+	flags   *flag.FlagSet
+	// flagValues maps a flag name to the completion kind of its value.
+	flagValues map[string]string
+	// args is the completion kind of positional arguments.
+	args     string
+	run      func(args []string) int
+	commands []*command
+}
+
+func newCommand(name, summary string) *command {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Usage = func() {}
+	return &command{name: name, summary: summary, flags: fs, flagValues: map[string]string{}}
+}
+
+func (c *command) add(children ...*command) *command {
+	c.commands = append(c.commands, children...)
+	return c
+}
+
+func (c *command) find(name string) *command {
+	for _, child := range c.commands {
+		if child.name == name {
+			return child
+		}
+	}
+	return nil
+}
+
+func (c *command) visibleCommands() []*command {
+	var out []*command
+	for _, child := range c.commands {
+		if !child.hidden {
+			out = append(out, child)
+		}
+	}
+	return out
+}
+
+// resolve walks args down the command tree and returns the deepest matching
+// command, its path from the root and the remaining arguments.
+func (c *command) resolve(args []string) (*command, []string, []string) {
+	cmd := c
+	path := []string{c.name}
+	for len(args) > 0 {
+		child := cmd.find(args[0])
+		if child == nil {
+			break
+		}
+		cmd = child
+		path = append(path, child.name)
+		args = args[1:]
+	}
+	return cmd, path, args
+}
+
+// execute dispatches args (without the program name) to the matching
+// command and returns its exit code.
+func execute(root *command, args []string, stdout, stderr io.Writer) int {
+	cmd, path, rest := root.resolve(args)
+	if cmd.rawArgs {
+		return cmd.run(rest)
+	}
+
+	if err := cmd.flags.Parse(rest); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printCommandHelp(stdout, cmd, path)
+			return 0
+		}
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		fmt.Fprintf(stderr, "Run '%s --help' for usage.\n", strings.Join(path, " "))
+		return 2
+	}
+
+	if cmd.run == nil {
+		if cmd.flags.NArg() > 0 {
+			fmt.Fprintf(stderr, "Error: unknown command %q for '%s'\n", cmd.flags.Arg(0), strings.Join(path, " "))
+		}
+		printCommandHelp(stderr, cmd, path)
+		return 2
+	}
+	return cmd.run(cmd.flags.Args())
+}
+
+func printCommandHelp(w io.Writer, cmd *command, path []string) {
+	fullName := strings.Join(path, " ")
+	fmt.Fprintf(w, "%s - %s\n\n", fullName, cmd.summary)
+
+	fmt.Fprintln(w, "USAGE:")
+	usage := fullName
+	if hasFlags(cmd.flags) {
+		usage += " [OPTIONS]"
+	}
+	if cmd.usage != "" {
+		usage += " " + cmd.usage
+	}
+	if cmd.run != nil {
+		fmt.Fprintf(w, "    %s\n", usage)
+	}
+	if children := cmd.visibleCommands(); len(children) > 0 {
+		fmt.Fprintf(w, "    %s <command>\n", fullName)
+
+		fmt.Fprintln(w, "\nCOMMANDS:")
+		tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+		for _, child := range children {
+			fmt.Fprintf(tw, "    %s\t%s\n", child.name, child.summary)
+		}
+		tw.Flush()
+	}
+
+	fmt.Fprintln(w, "\nOPTIONS:")
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	cmd.flags.VisitAll(func(f *flag.Flag) {
+		name, usage := flag.UnquoteUsage(f)
+		label := flagLabel(f.Name)
+		if name != "" {
+			label += " " + strings.ToUpper(name)
+		}
+		fmt.Fprintf(tw, "    %s\t%s\n", label, usage)
+	})
+	fmt.Fprintf(tw, "    %s\t%s\n", "-h, --help", "Show this help message")
+	tw.Flush()
+
+	if cmd.long != "" {
+		fmt.Fprintf(w, "\n%s", strings.TrimLeft(cmd.long, "\n"))
+	}
+	if len(cmd.visibleCommands()) > 0 {
+		fmt.Fprintf(w, "\nRun '%s <command> --help' for details on a command.\n", fullName)
+	}
+}
+
+func hasFlags(fs *flag.FlagSet) bool {
+	found := false
+	fs.VisitAll(func(*flag.Flag) { found = true })
+	return found
+}
+
+// flagLabel renders single-letter flags with one dash and long flags with
+// two, matching how they are documented.
+func flagLabel(name string) string {
+	if len(name) == 1 {
+		return "-" + name
+	}
+	return "--" + name
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// settingsFlags are the config overrides shared by every command that
+// runs tests.
+type settingsFlags struct {
+	theme   *string
+	args    *string
+	exclude *string
+}
+
+func addSettingsFlags(cmd *command) settingsFlags {
+	cmd.flagValues["theme"] = completeThemes
+	return settingsFlags{
+		theme:   cmd.flags.String("theme", "", "Override the configured `theme`"),
+		args:    cmd.flags.String("args", "", "Extra `arguments` passed to the test command, e.g. \"--trace\""),
+		exclude: cmd.flags.String("exclude", "", "Comma-separated `globs` of test files to hide"),
+	}
+}
+
+func (f settingsFlags) overrides() config.FlagOverrides {
+	return config.FlagOverrides{
+		Theme:   *f.theme,
+		RunArgs: strings.Fields(*f.args),
+		Exclude: splitCommaList(*f.exclude),
+	}
+}
+
+// workingDir returns the current directory, reporting a failure on stderr.
+func workingDir() (string, bool) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: could not get current directory: %v\n", err)
+		return "", false
+	}
+	return cwd, true
+}
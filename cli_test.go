@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samrobinsonsauce/eztest/internal/config"
+	"github.com/samrobinsonsauce/eztest/internal/testfile"
+)
+
+func testCompletionSources() completionSources {
+	return completionSources{
+		files: func() []string {
+			return []string{"test/accounts/user_test.exs", "test/page_test.exs"}
+		},
+		sets: func() []string { return []string{"smoke", "slow"} },
+	}
+}
+
+func TestCompleteCommandsFlagsAndValues(t *testing.T) {
+	root := newRootCommand()
+
+	cases := []struct {
+		words []string
+		want  []string
+	}{
+		{[]string{"st"}, []string{"state"}},
+		{[]string{"state", ""}, []string{"list", "prune", "delete"}},
+		{[]string{"run", "test/a"}, []string{"test/accounts/user_test.exs"}},
+		{[]string{"run", "-s", "s"}, []string{"smoke", "slow"}},
+		{[]string{"-s", "sm"}, []string{"smoke"}},
+		{[]string{"failed", "--l"}, []string{"--list"}},
+		{[]string{"completion", "z"}, []string{"zsh"}},
+		{[]string{"help", "config", ""}, []string{"show", "check"}},
+		{[]string{"run", "--args", "--trace", "test/p"}, []string{"test/page_test.exs"}},
+	}
+
+	for _, tc := range cases {
+		got := complete(root, tc.words, testCompletionSources())
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("complete(%q) = %v, want %v", tc.words, got, tc.want)
+		}
+	}
+}
+
+func TestCompleteHidesInternalCommand(t *testing.T) {
+	for _, c := range complete(newRootCommand(), []string{""}, testCompletionSources()) {
+		if strings.HasPrefix(c, "__") {
+			t.Fatalf("hidden command %q offered as a completion", c)
+		}
+	}
+}
+
+func TestExecutePrintsGeneratedHelp(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := execute(newRootCommand(), []string{"state", "delete", "--help"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d (stderr: %s)", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "ezt state delete [project-dir]") {
+		t.Fatalf("help is missing usage line:\n%s", stdout.String())
+	}
+}
+
+func TestExecuteRejectsUnknownFlagsAndCommands(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := execute(newRootCommand(), []string{"history", "--nope"}, &stdout, &stderr); code != 2 {
+		t.Fatalf("expected exit code 2 for unknown flag, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "ezt history --help") {
+		t.Fatalf("expected a usage hint, got %q", stderr.String())
+	}
+
+	stderr.Reset()
+	if code := execute(newRootCommand(), []string{"config", "nope"}, &stdout, &stderr); code != 2 {
+		t.Fatalf("expected exit code 2 for unknown subcommand, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), `unknown command "nope"`) {
+		t.Fatalf("expected unknown command error, got %q", stderr.String())
+	}
+}
+
+func TestResolveInputsMapsPathsAndSkipsUnknown(t *testing.T) {
+	files := []testfile.TestFile{
+		{Path: "test/accounts/user_test.exs"},
+		{Path: "test/accounts/team_test.exs"},
+		{Path: "test/page_test.exs"},
+	}
+
+	inputs := []string{
+		"test/accounts",
+		"/work/app/test/page_test.exs:12",
+		"lib/accounts/user.ex",
+		"test/accounts:3",
+		"mix.lock",
+	}
+	got, skipped := resolveInputs("/work/app", inputs, files)
+	want := []string{"test/accounts/user_test.exs", "test/accounts/team_test.exs", "test/page_test.exs:12"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected paths: got %v want %v", got, want)
+	}
+	if !reflect.DeepEqual(skipped, []string{"mix.lock"}) {
+		t.Fatalf("unexpected skipped inputs: %v", skipped)
+	}
+}
+
+func TestReadInputsIgnoresBlankLines(t *testing.T) {
+	got, err := readInputs(strings.NewReader("lib/a.ex\n\n  test/b_test.exs  \n"))
+	if err != nil {
+		t.Fatalf("readInputs returned error: %v", err)
+	}
+	want := []string{"lib/a.ex", "test/b_test.exs"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v want %v", got, want)
+	}
+}
+
+func TestPrintHistoryNewestFirst(t *testing.T) {
+	history := []config.RunRecord{
+		{StartedAt: time.Date(2024, 1, 1, 10, 0, 0, 0, time.Local), Duration: 1500 * time.Millisecond, Files: []string{"a"}},
+		{StartedAt: time.Date(2024, 1, 2, 10, 0, 0, 0, time.Local), Duration: time.Second, Files: []string{"a", "b"}, Failed: []string{"b"}, ExitCode: 2},
+	}
+
+	var out bytes.Buffer
+	printHistory(&out, history, 0, true)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %q", out.String())
+	}
+	if !strings.HasPrefix(lines[0], "2024-01-02 10:00") || !strings.Contains(lines[0], "1 failed (exit 2)") {
+		t.Fatalf("unexpected newest line %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "b") || !strings.Contains(lines[2], "passed") {
+		t.Fatalf("unexpected output:\n%s", out.String())
+	}
+
+	out.Reset()
+	printHistory(&out, history, 1, false)
+	if n := strings.Count(out.String(), "\n"); n != 1 {
+		t.Fatalf("expected limit to keep 1 line, got %d", n)
+	}
+}
+
+func TestFilterFilesUsesSavedFailures(t *testing.T) {
+	setupConfigEnv(t)
+	p := project{
+		dir: "/tmp/filter_project",
+		files: []testfile.TestFile{
+			{Path: "test/api/user_test.exs"},
+			{Path: "test/api/team_test.exs"},
+			{Path: "test/web/user_controller_test.exs"},
+		},
+	}
+	if err := config.SaveProjectFailures(p.dir, []string{"test/api/team_test.exs", "test/web/user_controller_test.exs"}); err != nil {
+		t.Fatalf("SaveProjectFailures returned error: %v", err)
+	}
+
+	got, err := filterFiles(p, "user @failed")
+	if err != nil {
+		t.Fatalf("filterFiles returned error: %v", err)
+	}
+	want := []string{"test/web/user_controller_test.exs"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected filtered files: got %v want %v", got, want)
+	}
+
+	if _, err := filterFiles(p, "@bogus"); err == nil {
+		t.Fatal("expected an error for an invalid query")
+	}
+}
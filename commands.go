@@ -0,0 +1,661 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/samrobinsonsauce/eztest/internal/config"
+	"github.com/samrobinsonsauce/eztest/internal/doctor"
+	"github.com/samrobinsonsauce/eztest/internal/testfile"
+	"github.com/samrobinsonsauce/eztest/internal/tui"
+)
+
+// newRootCommand builds the full ezt command tree. Running ezt without a
+// command opens the TUI.
+func newRootCommand() *command {
+	root := newCommand("ezt", "Elixir Test Selector")
+	root.usage = "[paths...]"
+	root.args = completeFiles
+	root.long = rootHelpText()
+	runDirect := root.flags.Bool("r", false, "Run saved tests, or the given paths, directly (skip TUI)")
+	runFailed := root.flags.Bool("f", false, "Run last failed tests directly (skip TUI)")
+	runSet := root.flags.String("s", "", "Run a named selection `set` directly (skip TUI)")
+	root.flagValues["s"] = completeSets
+	fromStdin := root.flags.Bool("stdin", false, "Read paths from stdin, one per line")
+	showVersion := root.flags.Bool("version", false, "Show version information")
+	settings := addSettingsFlags(root)
+	root.run = func(args []string) int {
+		if len(args) == 1 && looksLikeCommand(args[0]) {
+			fmt.Fprintf(os.Stderr, "Error: unknown command %q\nRun 'ezt --help' for usage.\n", args[0])
+			return 2
+		}
+		if *showVersion {
+			printVersion()
+			return 0
+		}
+		if countTrue(*runDirect, *runFailed, *runSet != "") > 1 {
+			fmt.Fprintf(os.Stderr, "Use only one of -r, -f or -s.\n")
+			return 1
+		}
+		hasInputs := len(args) > 0 || *fromStdin
+		if hasInputs && (*runFailed || *runSet != "") {
+			fmt.Fprintf(os.Stderr, "Paths and --stdin cannot be combined with -f or -s.\n")
+			return 1
+		}
+
+		p, ok := loadProject(settings.overrides())
+		if !ok {
+			return 1
+		}
+		inputs, ok := gatherInputs(p, args, *fromStdin)
+		if !ok {
+			return 1
+		}
+
+		switch {
+		case *runDirect && len(inputs) > 0:
+			return runAndPersistFailures(p.dir, p.settings.Run, inputs)
+		case *runDirect:
+			return runSavedSelection(p)
+		case *runFailed:
+			return runLastFailures(p)
+		case *runSet != "":
+			return runNamedSet(p, *runSet)
+		}
+		return runTUI(p, stripLineSuffixes(inputs), *fromStdin)
+	}
+
+	root.add(
+		newRunCommand(),
+		newFailedCommand(),
+		newListCommand(),
+		newHistoryCommand(),
+		newSetsCommand(),
+		newConfigCommand(),
+		newStateCommand(),
+		newDoctorCommand(),
+		newCompletionCommand(),
+		newVersionCommand(),
+		newHelpCommand(root),
+		newCompleteCommand(root),
+	)
+	return root
+}
+
+func rootHelpText() string {
+	configPath, err := config.GetAppConfigPath()
+	if err != nil {
+		configPath = "~/.config/eztest/config.json"
+	}
+	stateDir, err := config.GetStateDir()
+	if err != nil {
+		stateDir = "~/.config/eztest"
+	}
+	stateDir = filepath.Join(stateDir, "projects")
+
+	return fmt.Sprintf(`KEYBINDINGS:
+    (defaults shown below; configurable in %s
+     or a project-local .eztest.json)
+
+%s
+    With "ui": {"modal": true} the TUI starts in normal mode, where the
+    keys above apply as well; / or i enters insert mode to search and Esc
+    returns from it. In normal mode Esc only leaves visual mode.
+
+    With "ui": {"mouse": true} the mouse can click rows and checkboxes and
+    scroll the list. It is off by default so the terminal's own text
+    selection keeps working.
+
+    A keybind with spaces is a sequence, for example "ctrl+x r" or
+    "space r f". Sequences starting with a printable key only apply in
+    normal mode. Esc cancels a pending sequence.
+
+EXAMPLES:
+    ezt                        Open TUI to select and run tests
+    ezt run                    Run previously saved tests directly
+    ezt test/accounts          Open TUI with the files under a directory preselected
+    ezt run test/accounts      Run every test file under a directory
+    git diff --name-only | ezt --stdin -r
+                               Run the tests for the files you changed
+    ezt run -s smoke           Run the "smoke" named set directly
+    ezt failed                 Run previously failed tests directly
+    ezt history                Show recent test runs
+    ezt completion zsh         Print the zsh completion script
+
+    -r, -f and -s NAME remain as shortcuts for 'ezt run', 'ezt failed'
+    and 'ezt run -s NAME'.
+
+    Navigate to your Elixir/Phoenix project and run 'ezt'.
+    Type to filter tests, use Tab to select, Enter to run.
+
+    Selections are saved per-project in %s
+`, configPath, tui.DefaultKeyMap().HelpText("    "), stateDir)
+}
+
+// looksLikeCommand reports whether a lone argument is more likely a
+// mistyped command than a path, so ezt can say so instead of warning that
+// it matched no test file.
+func looksLikeCommand(arg string) bool {
+	if strings.ContainsAny(arg, "/.*?[:") {
+		return false
+	}
+	_, err := os.Stat(arg)
+	return err != nil
+}
+
+func printVersion() {
+	fmt.Printf("ezt version %s (commit: %s, built: %s)\n", version, commit, date)
+}
+
+func newRunCommand() *command {
+	cmd := newCommand("run", "Run tests without opening the TUI")
+	cmd.usage = "[paths...]"
+	cmd.args = completeFiles
+	cmd.long = `Without arguments the saved selection is run. Paths may be test files,
+directories or globs relative to the project root, optionally with a
+:LINE suffix to run a single test. Source files under lib/ run their
+matching tests, so 'git diff --name-only | ezt run --stdin' works.
+`
+	setName := cmd.flags.String("s", "", "Run the named selection `set`")
+	cmd.flagValues["s"] = completeSets
+	filter := cmd.flags.String("filter", "", "Run every test file matching a search `query`")
+	fromStdin := cmd.flags.Bool("stdin", false, "Read paths from stdin, one per line")
+	settings := addSettingsFlags(cmd)
+	cmd.run = func(args []string) int {
+		if countTrue(*setName != "", *filter != "", len(args) > 0 || *fromStdin) > 1 {
+			fmt.Fprintf(os.Stderr, "Use only one of -s, --filter or paths.\n")
+			return 2
+		}
+
+		p, ok := loadProject(settings.overrides())
+		if !ok {
+			return 1
+		}
+		if *setName != "" {
+			return runNamedSet(p, *setName)
+		}
+		if *filter != "" {
+			files, err := filterFiles(p, *filter)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: invalid query: %v\n", err)
+				return 2
+			}
+			if len(files) == 0 {
+				fmt.Fprintf(os.Stderr, "No test files match %q.\n", *filter)
+				return 1
+			}
+			return runAndPersistFailures(p.dir, p.settings.Run, files)
+		}
+		if len(args) == 0 && !*fromStdin {
+			return runSavedSelection(p)
+		}
+
+		files, ok := gatherInputs(p, args, *fromStdin)
+		if !ok {
+			return 1
+		}
+		return runAndPersistFailures(p.dir, p.settings.Run, files)
+	}
+	return cmd
+}
+
+func newFailedCommand() *command {
+	cmd := newCommand("failed", "Re-run the tests that failed last time")
+	list := cmd.flags.Bool("list", false, "Print the failed test files instead of running them")
+	settings := addSettingsFlags(cmd)
+	cmd.run = func(args []string) int {
+		p, ok := loadProject(settings.overrides())
+		if !ok {
+			return 1
+		}
+		if !*list {
+			return runLastFailures(p)
+		}
+
+		failures := reconcileSaved(p.dir, "failure", failuresForProject(p.dir), p.files, config.SaveProjectFailures)
+		for _, path := range failures {
+			fmt.Println(path)
+		}
+		return 0
+	}
+	return cmd
+}
+
+func newListCommand() *command {
+	cmd := newCommand("list", "Print discovered test files, one per line")
+	cmd.long = `With --filter the matches are ranked like the TUI search box, best
+first. The query uses the same language as the search box: fuzzy terms,
+'literal, /regex/, dir:PATH, app:NAME, @selected, @unselected, @failed,
+@changed, !negation and | for OR. The command exits 1 when nothing
+matches and 2 when the query is invalid.
+`
+	selected := cmd.flags.Bool("selected", false, "Print only the saved selection")
+	filter := cmd.flags.String("filter", "", "Print only files matching a search `query`, best match first")
+	cmd.run = func(args []string) int {
+		if *selected && *filter != "" {
+			fmt.Fprintf(os.Stderr, "Use either --selected or --filter, not both.\n")
+			return 2
+		}
+
+		p, ok := loadProject(config.FlagOverrides{})
+		if !ok {
+			return 1
+		}
+
+		if *filter != "" {
+			files, err := filterFiles(p, *filter)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: invalid query: %v\n", err)
+				return 2
+			}
+			for _, path := range files {
+				fmt.Println(path)
+			}
+			if len(files) == 0 {
+				return 1
+			}
+			return 0
+		}
+
+		if *selected {
+			selections, err := config.GetProjectSelections(p.dir)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error loading saved tests: %v\n", err)
+				return 1
+			}
+			for _, path := range reconcileSaved(p.dir, "selection", selections, p.files, config.SaveProjectSelections) {
+				fmt.Println(path)
+			}
+			return 0
+		}
+
+		for _, tf := range p.files {
+			fmt.Println(tf.Path)
+		}
+		return 0
+	}
+	return cmd
+}
+
+func newHistoryCommand() *command {
+	cmd := newCommand("history", "Show recent test runs for this project")
+	limit := cmd.flags.Int("n", 20, "Show at most `count` runs (0 for all)")
+	verbose := cmd.flags.Bool("v", false, "List the failed files of each run")
+	cmd.run = func(args []string) int {
+		cwd, ok := workingDir()
+		if !ok {
+			return 1
+		}
+		history, err := config.GetProjectHistory(cwd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading history: %v\n", err)
+			return 1
+		}
+		printHistory(os.Stdout, history, *limit, *verbose)
+		return 0
+	}
+	return cmd
+}
+
+// printHistory prints runs newest first.
+func printHistory(w io.Writer, history []config.RunRecord, limit int, verbose bool) {
+	if len(history) == 0 {
+		fmt.Fprintln(w, "No test runs recorded yet.")
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for i, shown := len(history)-1, 0; i >= 0 && (limit <= 0 || shown < limit); i, shown = i-1, shown+1 {
+		r := history[i]
+		status := "passed"
+		if r.ExitCode != 0 {
+			status = fmt.Sprintf("%d failed (exit %d)", len(r.Failed), r.ExitCode)
+		}
+		fmt.Fprintf(tw, "%s\t%d file(s)\t%s\t%s\n",
+			r.StartedAt.Local().Format("2006-01-02 15:04"), len(r.Files), r.Duration.Round(100*time.Millisecond), status)
+		if verbose {
+			for _, path := range r.Failed {
+				fmt.Fprintf(tw, "\t  %s\t\t\n", path)
+			}
+		}
+	}
+	tw.Flush()
+}
+
+func newSetsCommand() *command {
+	cmd := newCommand("sets", "List named selection sets")
+	cmd.run = func(args []string) int {
+		p, ok := loadProject(config.FlagOverrides{})
+		if !ok {
+			return 1
+		}
+		return listNamedSets(resolveNamedSets(p.dir, savedSets(p.dir), p.settings, p.files))
+	}
+	return cmd
+}
+
+func newConfigCommand() *command {
+	cmd := newCommand("config", "Inspect and validate configuration")
+
+	show := newCommand("show", "Print the effective config and where each value came from")
+	show.run = func(args []string) int {
+		cwd, ok := workingDir()
+		if !ok {
+			return 1
+		}
+		settings, err := config.LoadSettings(cwd, config.FlagOverrides{})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		printEffectiveConfig(os.Stdout, settings)
+		return 0
+	}
+
+	check := newCommand("check", "Validate config files and print the resolved key map")
+	check.run = func(args []string) int {
+		cwd, ok := workingDir()
+		if !ok {
+			return 1
+		}
+		return checkConfig(os.Stdout, cwd)
+	}
+
+	return cmd.add(show, check)
+}
+
+func newStateCommand() *command {
+	cmd := newCommand("state", "Manage saved per-project state")
+
+	list := newCommand("list", "List every project with saved state")
+	list.run = func(args []string) int {
+		return listProjectStates()
+	}
+
+	prune := newCommand("prune", "Drop saved selections and failures whose files no longer exist")
+	prune.run = func(args []string) int {
+		p, ok := loadProject(config.FlagOverrides{})
+		if !ok {
+			return 1
+		}
+		return pruneProjectState(p.dir, p.files)
+	}
+
+	del := newCommand("delete", "Forget everything saved for a project (default: the current one)")
+	del.usage = "[project-dir]"
+	del.run = func(args []string) int {
+		target, ok := workingDir()
+		if !ok {
+			return 1
+		}
+		if len(args) > 0 {
+			var err error
+			target, err = filepath.Abs(args[0])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return 1
+			}
+		}
+		return deleteProjectState(target)
+	}
+
+	return cmd.add(list, prune, del)
+}
+
+func newDoctorCommand() *command {
+	cmd := newCommand("doctor", "Check mix, Elixir, project layout, config and state")
+	asJSON := cmd.flags.Bool("json", false, "Print results as JSON")
+	cmd.run = func(args []string) int {
+		cwd, ok := workingDir()
+		if !ok {
+			return 1
+		}
+
+		settings, _ := config.LoadSettings(cwd, config.FlagOverrides{})
+		results := doctor.Run(doctor.DefaultEnv(cwd, settings, configCheckRules()))
+
+		if *asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(results); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				return 1
+			}
+		} else {
+			printDoctorResults(os.Stdout, results)
+		}
+
+		if doctor.Failed(results) {
+			return 1
+		}
+		return 0
+	}
+	return cmd
+}
+
+func newVersionCommand() *command {
+	cmd := newCommand("version", "Show version information")
+	cmd.run = func(args []string) int {
+		printVersion()
+		return 0
+	}
+	return cmd
+}
+
+func newHelpCommand(root *command) *command {
+	cmd := newCommand("help", "Show help for a command")
+	cmd.usage = "[command...]"
+	cmd.args = completeCommands
+	cmd.run = func(args []string) int {
+		target, path, rest := root.resolve(args)
+		if len(rest) > 0 {
+			fmt.Fprintf(os.Stderr, "Error: unknown command %q\n", strings.Join(args, " "))
+			return 2
+		}
+		printCommandHelp(os.Stdout, target, path)
+		return 0
+	}
+	return cmd
+}
+
+func listProjectStates() int {
+	projects, err := config.ListProjects()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error listing projects: %v\n", err)
+		return 1
+	}
+	if len(projects) == 0 {
+		fmt.Println("No saved project state.")
+		return 0
+	}
+
+	for _, p := range projects {
+		updated := "never"
+		if !p.UpdatedAt.IsZero() {
+			updated = p.UpdatedAt.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Printf("%s\t%d selected\t%d failing\tupdated %s\n", p.Root, len(p.Selections), len(p.Failures), updated)
+	}
+	return 0
+}
+
+func deleteProjectState(projectDir string) int {
+	deleted, err := config.DeleteProjectState(projectDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error deleting state for %s: %v\n", projectDir, err)
+		return 1
+	}
+	if !deleted {
+		fmt.Printf("No saved state for %s.\n", projectDir)
+		return 0
+	}
+	fmt.Printf("Deleted saved state for %s.\n", projectDir)
+	return 0
+}
+
+func pruneProjectState(projectDir string, testFiles []testfile.TestFile) int {
+	selections, err := config.GetProjectSelections(projectDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading saved tests: %v\n", err)
+		return 1
+	}
+	failures, err := config.GetProjectFailures(projectDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading failed tests: %v\n", err)
+		return 1
+	}
+
+	selRes := testfile.ResolveSaved(projectDir, selections, testFiles)
+	failRes := testfile.ResolveSaved(projectDir, failures, testFiles)
+
+	if selRes.Changed() {
+		if err := config.SaveProjectSelections(projectDir, selRes.Paths); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving selections: %v\n", err)
+			return 1
+		}
+	}
+	if failRes.Changed() {
+		if err := config.SaveProjectFailures(projectDir, failRes.Paths); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving failures: %v\n", err)
+			return 1
+		}
+	}
+
+	for _, path := range selRes.Missing {
+		fmt.Printf("removed selection %s\n", path)
+	}
+	for _, path := range failRes.Missing {
+		fmt.Printf("removed failure %s\n", path)
+	}
+	fmt.Printf("Pruned %d selection(s) and %d failure(s); migrated %d renamed path(s).\n",
+		len(selRes.Missing), len(failRes.Missing), len(selRes.Renamed)+len(failRes.Renamed))
+	return 0
+}
+
+func printDoctorResults(w io.Writer, results []doctor.Result) {
+	icons := map[doctor.Status]string{
+		doctor.Pass: "✓",
+		doctor.Warn: "!",
+		doctor.Fail: "✗",
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s %s\t%s\t%s\n", icons[r.Status], strings.ToUpper(string(r.Status)), r.Name, r.Message)
+		if r.Hint != "" && r.Status != doctor.Pass {
+			fmt.Fprintf(tw, "\t\t  → %s\n", r.Hint)
+		}
+	}
+	tw.Flush()
+}
+
+func configCheckRules() config.CheckRules {
+	return config.CheckRules{
+		Actions:      tui.ActionNames(),
+		Defaults:     tui.DefaultBindings(),
+		NormalizeKey: tui.NormalizeKeyName,
+		ValidKey:     tui.IsValidKeyName,
+		ValidTheme:   tui.IsKnownTheme,
+		Colors:       tui.ColorNames(),
+		ValidColor:   tui.IsValidColor,
+	}
+}
+
+// checkConfig validates every config file that applies to projectDir and
+// prints the problems followed by the resolved key map and settings. It
+// returns 1 when any error-level issue was found.
+func checkConfig(w io.Writer, projectDir string) int {
+	files := config.ConfigFiles(projectDir)
+	issues := config.CheckFiles(files, configCheckRules())
+
+	if len(files) == 0 {
+		fmt.Fprintln(w, "No config files found; using built-in defaults.")
+	} else {
+		fmt.Fprintln(w, "Config files (lowest precedence first):")
+		for _, file := range files {
+			fmt.Fprintf(w, "  %s\n", file)
+		}
+	}
+	fmt.Fprintln(w)
+
+	errorCount := 0
+	for _, issue := range issues {
+		if issue.Severity == config.SeverityError {
+			errorCount++
+		}
+		fmt.Fprintln(w, issue.String())
+	}
+	if len(issues) == 0 {
+		fmt.Fprintln(w, "No problems found.")
+	} else {
+		fmt.Fprintf(w, "\n%d error(s), %d warning(s)\n", errorCount, len(issues)-errorCount)
+	}
+
+	settings, err := config.LoadSettings(projectDir, config.FlagOverrides{})
+	if err != nil {
+		fmt.Fprintf(w, "\nLoad error: %v\n", err)
+	}
+
+	fmt.Fprintf(w, "\nResolved key map (theme: %s):\n", tui.ResolveTheme(settings.Theme).Name)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, ab := range tui.NewKeyMap(settings.Keybinds).WithCustomActions(customActions(settings)).Bindings() {
+		help := ab.Binding.Help()
+		fmt.Fprintf(tw, "  %s\t%s\t%s\n", ab.Action, help.Key, help.Desc)
+	}
+	tw.Flush()
+
+	fmt.Fprintln(w, "\nResolved settings:")
+	printEffectiveConfig(w, settings)
+
+	if errorCount > 0 {
+		return 1
+	}
+	return 0
+}
+
+func printEffectiveConfig(w io.Writer, settings config.AppSettings) {
+	values := map[string]string{
+		"theme":              settings.Theme,
+		"ui.animations":      strconv.FormatBool(settings.UI.Animations),
+		"ui.compact_help":    strconv.FormatBool(settings.UI.CompactHelp),
+		"ui.modal":           strconv.FormatBool(settings.UI.Modal),
+		"ui.mouse":           strconv.FormatBool(settings.UI.Mouse),
+		"ui.restore_session": strconv.FormatBool(settings.UI.RestoreSession),
+		"run.command":        strings.Join(settings.Run.Command, " "),
+		"run.args":           strings.Join(settings.Run.Args, " "),
+		"open.command":       settings.Open.Command,
+		"test_paths":         strings.Join(settings.TestPaths, ", "),
+		"exclude":            strings.Join(settings.Exclude, ", "),
+		"pinned":             strings.Join(settings.Pinned, ", "),
+		"hidden":             strings.Join(settings.Hidden, ", "),
+	}
+	for action, keys := range settings.Keybinds {
+		values["keybinds."+action] = strings.Join(keys, ", ")
+	}
+	for name, value := range settings.Colors {
+		values["colors."+name] = value
+	}
+	for name, action := range settings.Actions {
+		values["actions."+name] = action.Command
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, name := range sortedKeys(values) {
+		value := values[name]
+		if value == "" {
+			value = "(none)"
+		}
+		source := settings.Sources[name]
+		if source == "" {
+			source = config.SourceDefault
+		}
+		fmt.Fprintf(tw, "%s\t%s\t(%s)\n", name, value, source)
+	}
+	tw.Flush()
+}
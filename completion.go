@@ -0,0 +1,250 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/samrobinsonsauce/eztest/internal/config"
+	"github.com/samrobinsonsauce/eztest/internal/testfile"
+	"github.com/samrobinsonsauce/eztest/internal/tui"
+)
+
+// The completion scripts are thin wrappers: they hand the words typed so far
+// to the hidden `__complete` command, which answers from the command tree
+// and the project's discovery index. Both binary names are registered.
+const bashCompletion = `# bash completion for ezt
+_ezt_complete() {
+    local IFS=$'\n'
+    COMPREPLY=($("${COMP_WORDS[0]}" __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F _ezt_complete ezt eztest
+`
+
+const zshCompletion = `#compdef ezt eztest
+# zsh completion for ezt
+_ezt() {
+    local -a candidates
+    candidates=("${(@f)$(${words[1]} __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    if (( ${#candidates} )) && [[ -n ${candidates[1]} ]]; then
+        compadd -Q -- "${candidates[@]}"
+    else
+        _files
+    fi
+}
+if [[ "${funcstack[1]}" == "_ezt" ]]; then
+    _ezt "$@"
+else
+    compdef _ezt ezt eztest
+fi
+`
+
+const fishCompletion = `# fish completion for ezt
+function __ezt_complete
+    set -l tokens (commandline -opc)
+    $tokens[1] __complete $tokens[2..-1] (commandline -ct) 2>/dev/null
+end
+complete -c ezt -f -a '(__ezt_complete)'
+complete -c eztest -f -a '(__ezt_complete)'
+`
+
+var completionScripts = map[string]string{
+	"bash": bashCompletion,
+	"zsh":  zshCompletion,
+	"fish": fishCompletion,
+}
+
+func newCompletionCommand() *command {
+	cmd := newCommand("completion", "Print a shell completion script")
+	cmd.usage = "<bash|zsh|fish>"
+	cmd.args = completeShells
+	cmd.long = `INSTALL:
+    bash   echo 'source <(ezt completion bash)' >> ~/.bashrc
+    zsh    ezt completion zsh > "${fpath[1]}/_ezt"
+    fish   ezt completion fish > ~/.config/fish/completions/ezt.fish
+
+Completions cover commands, flags, test file paths from the current
+project and named selection sets.
+`
+	cmd.run = func(args []string) int {
+		if len(args) != 1 {
+			fmt.Fprintf(os.Stderr, "Usage: ezt completion <bash|zsh|fish>\n")
+			return 2
+		}
+		script, ok := completionScripts[args[0]]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Error: unsupported shell %q (use bash, zsh or fish)\n", args[0])
+			return 2
+		}
+		fmt.Print(script)
+		return 0
+	}
+	return cmd
+}
+
+func newCompleteCommand(root *command) *command {
+	cmd := newCommand("__complete", "Print completion candidates for the given words")
+	cmd.hidden = true
+	cmd.rawArgs = true
+	cmd.run = func(args []string) int {
+		printCandidates(os.Stdout, complete(root, args, projectCompletionSources()))
+		return 0
+	}
+	return cmd
+}
+
+// completionSources supplies project data lazily so completing a command
+// name never pays for test discovery.
+type completionSources struct {
+	files func() []string
+	sets  func() []string
+}
+
+func projectCompletionSources() completionSources {
+	var loaded bool
+	var p project
+	load := func() bool {
+		if !loaded {
+			loaded = true
+			cwd, err := os.Getwd()
+			if err != nil {
+				return false
+			}
+			settings, _ := config.LoadSettings(cwd, config.FlagOverrides{})
+			testFiles, err := testfile.Discover(cwd, discoveryOptions(settings))
+			if err != nil {
+				testFiles = nil
+			}
+			p = project{dir: cwd, settings: settings, files: testFiles}
+		}
+		return p.dir != ""
+	}
+
+	return completionSources{
+		files: func() []string {
+			if !load() {
+				return nil
+			}
+			paths := make([]string, len(p.files))
+			for i, tf := range p.files {
+				paths[i] = tf.Path
+			}
+			return paths
+		},
+		sets: func() []string {
+			if !load() {
+				return nil
+			}
+			names := map[string]struct{}{}
+			if saved, err := config.GetProjectSets(p.dir); err == nil {
+				for name := range saved {
+					names[name] = struct{}{}
+				}
+			}
+			for name := range p.settings.Sets {
+				names[name] = struct{}{}
+			}
+			out := make([]string, 0, len(names))
+			for name := range names {
+				out = append(out, name)
+			}
+			sort.Strings(out)
+			return out
+		},
+	}
+}
+
+// complete returns the candidates for the last word in words, which holds
+// everything typed after the program name. The last word may be empty.
+func complete(root *command, words []string, src completionSources) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	current := words[len(words)-1]
+
+	cmd := root
+	var positional []string
+	pendingFlag := ""
+	for _, word := range words[:len(words)-1] {
+		if pendingFlag != "" {
+			pendingFlag = ""
+			continue
+		}
+		if strings.HasPrefix(word, "-") && word != "-" {
+			name := strings.TrimLeft(word, "-")
+			if f := cmd.flags.Lookup(name); f != nil && !isBoolFlag(f) {
+				pendingFlag = name
+			}
+			continue
+		}
+		if len(positional) == 0 {
+			if child := cmd.find(word); child != nil {
+				cmd = child
+				continue
+			}
+		}
+		positional = append(positional, word)
+	}
+
+	var candidates []string
+	switch {
+	case pendingFlag != "":
+		candidates = completionValues(cmd.flagValues[pendingFlag], root, positional, src)
+	case strings.HasPrefix(current, "-"):
+		cmd.flags.VisitAll(func(f *flag.Flag) {
+			candidates = append(candidates, flagLabel(f.Name))
+		})
+		candidates = append(candidates, "--help")
+	default:
+		if len(positional) == 0 {
+			for _, child := range cmd.visibleCommands() {
+				candidates = append(candidates, child.name)
+			}
+		}
+		candidates = append(candidates, completionValues(cmd.args, root, positional, src)...)
+	}
+
+	var out []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, current) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func completionValues(kind string, root *command, positional []string, src completionSources) []string {
+	switch kind {
+	case completeFiles:
+		return src.files()
+	case completeSets:
+		return src.sets()
+	case completeThemes:
+		return tui.ThemeNames()
+	case completeShells:
+		if len(positional) > 0 {
+			return nil
+		}
+		return sortedKeys(completionScripts)
+	case completeCommands:
+		target, _, rest := root.resolve(positional)
+		if len(rest) > 0 {
+			return nil
+		}
+		var names []string
+		for _, child := range target.visibleCommands() {
+			names = append(names, child.name)
+		}
+		return names
+	}
+	return nil
+}
+
+func printCandidates(w io.Writer, candidates []string) {
+	for _, c := range candidates {
+		fmt.Fprintln(w, c)
+	}
+}
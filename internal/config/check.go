@@ -0,0 +1,490 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Severity levels for config issues.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Issue is a single problem found in a config file.
+type Issue struct {
+	File     string
+	Line     int
+	Column   int
+	Key      string
+	Severity string
+	Message  string
+}
+
+func (i Issue) String() string {
+	location := i.File
+	if i.Line > 0 {
+		location = fmt.Sprintf("%s:%d:%d", i.File, i.Line, i.Column)
+	}
+	if i.Key != "" {
+		return fmt.Sprintf("%s: %s: %s: %s", location, i.Severity, i.Key, i.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", location, i.Severity, i.Message)
+}
+
+// CheckRules describes what a valid config looks like. The TUI owns actions,
+// keys, themes and colors, so the caller supplies them.
+type CheckRules struct {
+	Actions      []string
+	Defaults     map[string][]string
+	NormalizeKey func(string) string
+	ValidKey     func(string) bool
+	ValidTheme   func(string) bool
+	Colors       []string
+	ValidColor   func(string) bool
+}
+
+var knownSettingKeys = map[string][]string{
+	"":     {"theme", "keybinds", "ui", "run", "open", "test_paths", "exclude", "sets", "colors", "actions", "pinned", "hidden"},
+	"ui":   {"animations", "compact_help", "modal", "mouse", "restore_session"},
+	"run":  {"command", "args"},
+	"open": {"command"},
+}
+
+// ConfigFiles returns the config files that apply to projectDir, lowest
+// precedence first. Files that don't exist are omitted.
+func ConfigFiles(projectDir string) []string {
+	var files []string
+	if path, err := GetAppConfigPath(); err == nil {
+		if _, err := os.Stat(path); err == nil {
+			files = append(files, path)
+		} else if legacy, err := getLegacyAppConfigPath(); err == nil {
+			if _, err := os.Stat(legacy); err == nil {
+				files = append(files, legacy)
+			}
+		}
+	}
+	if path := FindProjectConfig(projectDir); path != "" {
+		files = append(files, path)
+	}
+	return files
+}
+
+// boundKey remembers which file and layer overrode an action's keys, and
+// the setting that holds them.
+type boundKey struct {
+	action string
+	key    string
+	file   string
+	layer  int
+	pos    position
+}
+
+// CheckFiles validates each config file in layer order and reports every
+// problem with its file and JSON position. Keys bound to several actions are
+// detected across the merged result of all files and the defaults.
+func CheckFiles(files []string, rules CheckRules) []Issue {
+	var issues []Issue
+
+	keysByAction := map[string][]string{}
+	for action, keys := range rules.Defaults {
+		keysByAction[action] = append([]string(nil), keys...)
+	}
+	origin := map[string]boundKey{}
+
+	for layer, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			issues = append(issues, Issue{File: file, Severity: SeverityError, Message: err.Error()})
+			continue
+		}
+
+		fileIssues, overrides := checkData(file, data, rules)
+		issues = append(issues, fileIssues...)
+		for action, bound := range overrides {
+			bound.at.layer = layer
+			keysByAction[action] = bound.keys
+			origin[action] = bound.at
+		}
+	}
+
+	issues = append(issues, keyConflicts(keysByAction, origin)...)
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].File != issues[j].File {
+			return fileIndex(files, issues[i].File) < fileIndex(files, issues[j].File)
+		}
+		if issues[i].Line != issues[j].Line {
+			return issues[i].Line < issues[j].Line
+		}
+		return issues[i].Column < issues[j].Column
+	})
+	return issues
+}
+
+func fileIndex(files []string, file string) int {
+	for i, f := range files {
+		if f == file {
+			return i
+		}
+	}
+	return len(files)
+}
+
+type keyOverride struct {
+	keys []string
+	at   boundKey
+}
+
+func checkData(file string, data []byte, rules CheckRules) ([]Issue, map[string]keyOverride) {
+	var issues []Issue
+	overrides := map[string]keyOverride{}
+
+	issue := func(key string, pos position, severity, format string, args ...any) {
+		issues = append(issues, Issue{
+			File:     file,
+			Line:     pos.line,
+			Column:   pos.column,
+			Key:      key,
+			Severity: severity,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	positions, err := jsonPositions(data)
+	if err != nil {
+		var syntaxErr *json.SyntaxError
+		offset := int64(0)
+		if errors.As(err, &syntaxErr) {
+			offset = syntaxErr.Offset
+		}
+		issue("", offsetPosition(data, int(offset)), SeverityError, "invalid JSON: %v", err)
+		return issues, overrides
+	}
+	at := func(path string) position {
+		return positions[path]
+	}
+
+	var raw rawAppSettings
+	if err := json.Unmarshal(data, &raw); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			issue(typeErr.Field, offsetPosition(data, int(typeErr.Offset)), SeverityError, "expected %s, got %s", typeErr.Type, typeErr.Value)
+		} else {
+			issue("", position{}, SeverityError, "%v", err)
+		}
+		return issues, overrides
+	}
+
+	for parent, allowed := range knownSettingKeys {
+		for _, name := range positions.children(parent) {
+			if !contains(allowed, name) {
+				path := joinPath(parent, name)
+				issue(path, at(path), SeverityWarning, "unknown setting %q", name)
+			}
+		}
+	}
+
+	if theme := strings.TrimSpace(raw.Theme); theme != "" && rules.ValidTheme != nil && !rules.ValidTheme(theme) {
+		issue("theme", at("theme"), SeverityError, "unknown theme %q; the default theme will be used", raw.Theme)
+	}
+
+	for _, name := range positions.children("colors") {
+		path := joinPath("colors", name)
+		normalized := strings.ToLower(strings.TrimSpace(name))
+		if !contains(rules.Colors, normalized) {
+			issue(path, at(path), SeverityError, "unknown color %q (valid: %s)", name, strings.Join(rules.Colors, ", "))
+			continue
+		}
+		if value := raw.Colors[name]; rules.ValidColor != nil && !rules.ValidColor(value) {
+			issue(path, at(path), SeverityError, "invalid color %q; use #RRGGBB, #RGB or an ANSI number 0-255", value)
+		}
+	}
+
+	for _, rawAction := range positions.children("keybinds") {
+		path := joinPath("keybinds", rawAction)
+		action := strings.ToLower(strings.TrimSpace(rawAction))
+		if !contains(rules.Actions, action) {
+			issue(path, at(path), SeverityError, "unknown action %q", rawAction)
+			continue
+		}
+
+		keys := checkKeys(path, raw.Keybinds[rawAction], rules, at, issue)
+		if len(keys) == 0 {
+			issue(path, at(path), SeverityWarning, "no usable keys; the default binding is kept")
+			continue
+		}
+		overrides[action] = keyOverride{keys: keys, at: boundKey{action: action, key: path, file: file, pos: at(path)}}
+	}
+
+	for _, rawName := range positions.children("actions") {
+		path := joinPath("actions", rawName)
+		name := strings.ToLower(strings.TrimSpace(rawName))
+		action := raw.Actions[rawName]
+		for _, field := range positions.children(path) {
+			if !contains([]string{"description", "command", "keys"}, field) {
+				issue(joinPath(path, field), at(joinPath(path, field)), SeverityWarning, "unknown setting %q", field)
+			}
+		}
+		switch {
+		case name == "":
+			issue(path, at(path), SeverityError, "custom action needs a name")
+			continue
+		case contains(rules.Actions, name):
+			issue(path, at(path), SeverityError, "custom action %q has the name of a built-in action", rawName)
+			continue
+		case strings.TrimSpace(action.Command) == "":
+			issue(path, at(path), SeverityError, "custom action %q has no command and is ignored", rawName)
+			continue
+		}
+		keysPath := joinPath(path, "keys")
+		if keys := checkKeys(keysPath, action.Keys, rules, at, issue); len(keys) > 0 {
+			overrides[name] = keyOverride{keys: keys, at: boundKey{action: name, key: keysPath, file: file, pos: at(keysPath)}}
+		}
+	}
+
+	return issues, overrides
+}
+
+// checkKeys validates the keys listed at path and returns the usable ones,
+// normalized and without duplicates.
+func checkKeys(path string, rawKeys []string, rules CheckRules, at func(string) position, issue func(string, position, string, string, ...any)) []string {
+	var keys []string
+	seen := map[string]struct{}{}
+	for i, rawKey := range rawKeys {
+		keyPath := fmt.Sprintf("%s[%d]", path, i)
+		normalized := rawKey
+		if rules.NormalizeKey != nil {
+			normalized = rules.NormalizeKey(rawKey)
+		}
+		if normalized == "" {
+			issue(keyPath, at(keyPath), SeverityWarning, "empty key name is ignored")
+			continue
+		}
+		if rules.ValidKey != nil && !rules.ValidKey(normalized) {
+			issue(keyPath, at(keyPath), SeverityError, "unrecognized key %q", rawKey)
+			continue
+		}
+		if _, dup := seen[normalized]; dup {
+			continue
+		}
+		seen[normalized] = struct{}{}
+		keys = append(keys, normalized)
+	}
+	return keys
+}
+
+func keyConflicts(keysByAction map[string][]string, origin map[string]boundKey) []Issue {
+	actionsByKey := map[string][]string{}
+	for action, keys := range keysByAction {
+		for _, k := range keys {
+			actionsByKey[k] = append(actionsByKey[k], action)
+		}
+	}
+
+	var issues []Issue
+	for k, actions := range actionsByKey {
+		if len(actions) < 2 {
+			continue
+		}
+		sort.Strings(actions)
+
+		at, ok := lastOrigin(actions, origin)
+		if !ok {
+			continue
+		}
+		issues = append(issues, keyIssue(at, "key %s is bound to more than one action: %s", strconv.Quote(k), strings.Join(actions, ", ")))
+	}
+
+	// When a sequence such as "g g" starts with a key that is bound on its
+	// own ("g"), that binding waits for the sequence to time out.
+	for k, actions := range actionsByKey {
+		parts := sequenceParts(k)
+		for n := 1; n < len(parts); n++ {
+			prefix := strings.Join(parts[:n], " ")
+			if prefix == "space" {
+				prefix = " "
+			}
+			prefixActions, bound := actionsByKey[prefix]
+			if !bound {
+				continue
+			}
+			involved := append(append([]string(nil), actions...), prefixActions...)
+			sort.Strings(involved)
+			at, ok := lastOrigin(involved, origin)
+			if !ok {
+				continue
+			}
+			issue := keyIssue(at, "key %s is bound to %s and starts sequence %s; it only fires after the sequence times out", strconv.Quote(prefix), strings.Join(prefixActions, ", "), strconv.Quote(k))
+			issue.Severity = SeverityWarning
+			issues = append(issues, issue)
+			break
+		}
+	}
+	return issues
+}
+
+// lastOrigin finds where a conflict was introduced: the override that came
+// last among the involved actions. Conflicts among defaults have none.
+func lastOrigin(actions []string, origin map[string]boundKey) (boundKey, bool) {
+	var at boundKey
+	for _, action := range actions {
+		if o, ok := origin[action]; ok && (at.file == "" || o.layer >= at.layer) {
+			at = o
+		}
+	}
+	return at, at.file != ""
+}
+
+func keyIssue(at boundKey, format string, args ...any) Issue {
+	return Issue{
+		File:     at.file,
+		Line:     at.pos.line,
+		Column:   at.pos.column,
+		Key:      at.key,
+		Severity: SeverityError,
+		Message:  fmt.Sprintf(format, args...),
+	}
+}
+
+// sequenceParts splits a normalized multi-key binding such as "space r f"
+// into its keys. Single keys, including " ", return nil.
+func sequenceParts(k string) []string {
+	if k == " " || !strings.Contains(k, " ") {
+		return nil
+	}
+	return strings.Split(k, " ")
+}
+
+type position struct {
+	line   int
+	column int
+}
+
+// positionIndex maps a JSON path ("keybinds.up[1]") to where it appears.
+type positionIndex map[string]position
+
+func (p positionIndex) children(parent string) []string {
+	var out []string
+	prefix := parent + "."
+	if parent == "" {
+		prefix = ""
+	}
+	for path := range p {
+		if !strings.HasPrefix(path, prefix) || path == parent {
+			continue
+		}
+		rest := strings.TrimPrefix(path, prefix)
+		if rest == "" || strings.ContainsAny(rest, ".[") {
+			continue
+		}
+		out = append(out, rest)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func joinPath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+// jsonPositions walks the document and records the position of every
+// object key and array element.
+func jsonPositions(data []byte) (positionIndex, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	index := positionIndex{}
+	if err := walkJSON(dec, data, "", index); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err == nil {
+		return nil, &json.SyntaxError{Offset: dec.InputOffset()}
+	}
+	return index, nil
+}
+
+func walkJSON(dec *json.Decoder, data []byte, path string, index positionIndex) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return nil
+	}
+
+	switch delim {
+	case '{':
+		for dec.More() {
+			start := skipSeparators(data, int(dec.InputOffset()))
+			keyTok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			name, _ := keyTok.(string)
+			child := joinPath(path, name)
+			index[child] = offsetPosition(data, start)
+			if err := walkJSON(dec, data, child, index); err != nil {
+				return err
+			}
+		}
+	case '[':
+		for i := 0; dec.More(); i++ {
+			start := skipSeparators(data, int(dec.InputOffset()))
+			child := fmt.Sprintf("%s[%d]", path, i)
+			index[child] = offsetPosition(data, start)
+			if err := walkJSON(dec, data, child, index); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = dec.Token()
+	return err
+}
+
+func skipSeparators(data []byte, offset int) int {
+	for offset < len(data) {
+		switch data[offset] {
+		case ' ', '\t', '\r', '\n', ',', ':':
+			offset++
+		default:
+			return offset
+		}
+	}
+	return offset
+}
+
+func offsetPosition(data []byte, offset int) position {
+	if offset > len(data) {
+		offset = len(data)
+	}
+	line, column := 1, 1
+	for _, b := range data[:offset] {
+		if b == '\n' {
+			line++
+			column = 1
+			continue
+		}
+		column++
+	}
+	return position{line: line, column: column}
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
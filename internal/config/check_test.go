@@ -0,0 +1,150 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testCheckRules() CheckRules {
+	return CheckRules{
+		Actions: []string{"up", "down", "run"},
+		Defaults: map[string][]string{
+			"up":   {"up"},
+			"down": {"down"},
+			"run":  {"enter"},
+		},
+		NormalizeKey: normalizeKey,
+		ValidKey: func(k string) bool {
+			return k != "hyper+x"
+		},
+		ValidTheme: func(name string) bool {
+			return name == "default"
+		},
+		Colors: []string{"primary"},
+		ValidColor: func(v string) bool {
+			return strings.HasPrefix(v, "#")
+		},
+	}
+}
+
+func findIssue(issues []Issue, key string) (Issue, bool) {
+	for _, issue := range issues {
+		if issue.Key == key {
+			return issue, true
+		}
+	}
+	return Issue{}, false
+}
+
+func TestCheckFilesReportsProblemsWithPositions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeFile(t, path, `{
+  "theme": "solarized",
+  "keybinds": {
+    "jump": ["j"],
+    "up": ["hyper+x", "k"]
+  },
+  "colors": {"primary": "red"},
+  "extra": true
+}`)
+
+	issues := CheckFiles([]string{path}, testCheckRules())
+
+	tests := []struct {
+		key    string
+		line   int
+		column int
+	}{
+		{key: "theme", line: 2, column: 3},
+		{key: "keybinds.jump", line: 4, column: 5},
+		{key: "keybinds.up[0]", line: 5, column: 12},
+		{key: "colors.primary", line: 7, column: 14},
+		{key: "extra", line: 8, column: 3},
+	}
+	for _, tt := range tests {
+		issue, ok := findIssue(issues, tt.key)
+		if !ok {
+			t.Errorf("expected an issue for %s, got %v", tt.key, issues)
+			continue
+		}
+		if issue.File != path || issue.Line != tt.line || issue.Column != tt.column {
+			t.Errorf("issue for %s at %s:%d:%d, want %s:%d:%d", tt.key, issue.File, issue.Line, issue.Column, path, tt.line, tt.column)
+		}
+	}
+}
+
+func TestCheckFilesDetectsKeysBoundTwiceAcrossFiles(t *testing.T) {
+	dir := t.TempDir()
+	global := filepath.Join(dir, "global.json")
+	project := filepath.Join(dir, "project.json")
+	writeFile(t, global, `{"keybinds": {"up": ["k"]}}`)
+	writeFile(t, project, `{"keybinds": {"down": ["k"]}}`)
+
+	issues := CheckFiles([]string{global, project}, testCheckRules())
+	issue, ok := findIssue(issues, "keybinds.down")
+	if !ok {
+		t.Fatalf("expected a conflict reported on the project override, got %v", issues)
+	}
+	if issue.File != project || !strings.Contains(issue.Message, "down, up") {
+		t.Fatalf("unexpected conflict issue: %+v", issue)
+	}
+}
+
+func TestCheckFilesWarnsWhenSequencePrefixIsBound(t *testing.T) {
+	dir := t.TempDir()
+	global := filepath.Join(dir, "global.json")
+	project := filepath.Join(dir, "project.json")
+	writeFile(t, global, `{"keybinds": {"up": ["g"]}}`)
+	writeFile(t, project, `{"keybinds": {"run": ["g  g"], "down": ["space r f"]}}`)
+
+	issues := CheckFiles([]string{global, project}, testCheckRules())
+	issue, ok := findIssue(issues, "keybinds.run")
+	if !ok || len(issues) != 1 {
+		t.Fatalf("expected one prefix conflict on the run override, got %v", issues)
+	}
+	if issue.File != project || issue.Severity != SeverityWarning || !strings.Contains(issue.Message, `"g" is bound to up and starts sequence "g g"`) {
+		t.Fatalf("unexpected prefix conflict issue: %+v", issue)
+	}
+}
+
+func TestCheckFilesReportsSyntaxErrorPosition(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeFile(t, path, "{\n  \"theme\": \"default\",,\n}")
+
+	issues := CheckFiles([]string{path}, testCheckRules())
+	if len(issues) != 1 || issues[0].Line != 2 {
+		t.Fatalf("expected one syntax error on line 2, got %v", issues)
+	}
+}
+
+func TestCheckFilesCleanConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeFile(t, path, `{"theme": "default", "keybinds": {"up": ["k"]}, "ui": {"animations": false}}`)
+
+	if issues := CheckFiles([]string{path}, testCheckRules()); len(issues) != 0 {
+		t.Fatalf("expected no issues, got %v", issues)
+	}
+}
+
+func TestCheckFilesValidatesCustomActions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeFile(t, path, `{
+  "actions": {
+    "run": {"command": "mix run"},
+    "lint": {"description": "lint", "keys": ["l"]},
+    "credo": {"command": "mix credo {files}", "keys": ["enter"], "shell": true}
+  }
+}`)
+
+	issues := CheckFiles([]string{path}, testCheckRules())
+	for _, key := range []string{"actions.run", "actions.lint", "actions.credo.shell"} {
+		if _, ok := findIssue(issues, key); !ok {
+			t.Errorf("expected an issue for %s, got %v", key, issues)
+		}
+	}
+	issue, ok := findIssue(issues, "actions.credo.keys")
+	if !ok || !strings.Contains(issue.Message, "credo, run") {
+		t.Fatalf("expected a key conflict between credo and run, got %v", issues)
+	}
+}
@@ -15,31 +15,96 @@ const (
 	appFileName         = "config.json"
 )
 
-type State struct {
-	ProjectSelections map[string][]string `json:"project_selections"`
-	ProjectFailures   map[string][]string `json:"project_failures,omitempty"`
-}
-
 type AppSettings struct {
-	Theme    string              `json:"theme"`
-	Keybinds map[string][]string `json:"keybinds"`
-	UI       UISettings          `json:"ui"`
+	Theme     string              `json:"theme"`
+	Keybinds  map[string][]string `json:"keybinds"`
+	UI        UISettings          `json:"ui"`
+	Run       RunSettings         `json:"run"`
+	Open      OpenSettings        `json:"open"`
+	TestPaths []string            `json:"test_paths"`
+	Exclude   []string            `json:"exclude"`
+	Sets      map[string]SetRule  `json:"sets"`
+	Colors    map[string]string   `json:"colors"`
+	// Actions are custom commands offered in the TUI, keyed by name.
+	Actions map[string]CustomAction `json:"actions"`
+	// Pinned and Hidden are paths or globs pinned to the top of the TUI
+	// list or hidden from it, until the project changes them in the TUI.
+	Pinned []string `json:"pinned"`
+	Hidden []string `json:"hidden"`
+
+	// Sources records which layer supplied each effective value, keyed by
+	// the dotted setting name (for example "ui.animations").
+	Sources map[string]string `json:"-"`
 }
 
 type UISettings struct {
 	Animations  bool `json:"animations"`
 	CompactHelp bool `json:"compact_help"`
+	// Modal enables vim-style normal and insert modes.
+	Modal bool `json:"modal"`
+	// Mouse enables clicking and scrolling. It is off by default because
+	// mouse reporting stops the terminal's own text selection.
+	Mouse bool `json:"mouse"`
+	// RestoreSession reopens the TUI with the query and cursor it was left
+	// with in the project.
+	RestoreSession bool `json:"restore_session"`
+}
+
+// SetRule declares a named selection set in config. A file belongs to the
+// set when it matches any of the listed paths, globs or ExUnit tags.
+type SetRule struct {
+	Paths []string `json:"paths"`
+	Globs []string `json:"globs"`
+	Tags  []string `json:"tags"`
+}
+
+// CustomAction is a command the TUI can run on the selected files. Command
+// is a template such as "mix credo {files}": {files} becomes the selected
+// files and {file} the file under the cursor. Keys bind it like keybinds.
+type CustomAction struct {
+	Description string   `json:"description"`
+	Command     string   `json:"command"`
+	Keys        []string `json:"keys"`
+}
+
+// RunSettings controls how selected test files are executed.
+type RunSettings struct {
+	Command []string `json:"command"`
+	Args    []string `json:"args"`
+}
+
+// OpenSettings controls how the TUI opens a file. Command is a template
+// such as "code -g {file}:{line}"; when empty, $VISUAL or $EDITOR is used.
+type OpenSettings struct {
+	Command string `json:"command"`
 }
 
 type rawAppSettings struct {
-	Theme    string              `json:"theme"`
-	Keybinds map[string][]string `json:"keybinds"`
-	UI       rawUISettings       `json:"ui"`
+	Theme     string                  `json:"theme"`
+	Keybinds  map[string][]string     `json:"keybinds"`
+	UI        rawUISettings           `json:"ui"`
+	Run       rawRunSettings          `json:"run"`
+	Open      OpenSettings            `json:"open"`
+	TestPaths []string                `json:"test_paths"`
+	Exclude   []string                `json:"exclude"`
+	Sets      map[string]SetRule      `json:"sets"`
+	Colors    map[string]string       `json:"colors"`
+	Actions   map[string]CustomAction `json:"actions"`
+	Pinned    []string                `json:"pinned"`
+	Hidden    []string                `json:"hidden"`
 }
 
 type rawUISettings struct {
-	Animations  *bool `json:"animations"`
-	CompactHelp *bool `json:"compact_help"`
+	Animations     *bool `json:"animations"`
+	CompactHelp    *bool `json:"compact_help"`
+	Modal          *bool `json:"modal"`
+	Mouse          *bool `json:"mouse"`
+	RestoreSession *bool `json:"restore_session"`
+}
+
+type rawRunSettings struct {
+	Command []string `json:"command"`
+	Args    []string `json:"args"`
 }
 
 func getConfigDir() (string, error) {
@@ -50,6 +115,11 @@ func getConfigDir() (string, error) {
 	return filepath.Join(baseDir, configDirName), nil
 }
 
+// GetLegacyConfigDir returns the pre-rename ~/.config/ezt directory.
+func GetLegacyConfigDir() (string, error) {
+	return getLegacyConfigDir()
+}
+
 func getLegacyConfigDir() (string, error) {
 	baseDir, err := getBaseConfigDir()
 	if err != nil {
@@ -70,14 +140,6 @@ func getBaseConfigDir() (string, error) {
 	return filepath.Join(home, ".config"), nil
 }
 
-func getStatePath() (string, error) {
-	dir, err := getConfigDir()
-	if err != nil {
-		return "", err
-	}
-	return filepath.Join(dir, stateFileName), nil
-}
-
 func GetAppConfigPath() (string, error) {
 	dir, err := getConfigDir()
 	if err != nil {
@@ -87,126 +149,191 @@ func GetAppConfigPath() (string, error) {
 }
 
 func DefaultAppSettings() AppSettings {
-	return AppSettings{
+	settings := AppSettings{
 		Theme:    "default",
 		Keybinds: map[string][]string{},
 		UI: UISettings{
-			Animations:  true,
-			CompactHelp: false,
+			Animations:     true,
+			CompactHelp:    false,
+			Mouse:          false,
+			RestoreSession: true,
 		},
-	}
+		Run: RunSettings{
+			Command: []string{"mix", "test"},
+			Args:    []string{},
+		},
+		TestPaths: []string{"test", "apps/*/test"},
+		Exclude:   []string{},
+		Sets:      map[string]SetRule{},
+		Colors:    map[string]string{},
+		Actions:   map[string]CustomAction{},
+		Pinned:    []string{},
+		Hidden:    []string{},
+		Sources:   map[string]string{},
+	}
+	for _, name := range []string{"theme", "ui.animations", "ui.compact_help", "ui.modal", "ui.mouse", "ui.restore_session", "run.command", "run.args", "open.command", "test_paths", "exclude", "pinned", "hidden"} {
+		settings.Sources[name] = SourceDefault
+	}
+	return settings
 }
 
-func LoadState() (*State, error) {
-	configPath, err := getStatePath()
+func readGlobalSettings() (*rawAppSettings, string, error) {
+	configPath, err := GetAppConfigPath()
 	if err != nil {
-		return &State{
-			ProjectSelections: make(map[string][]string),
-			ProjectFailures:   make(map[string][]string),
-		}, nil
+		return nil, "", nil
 	}
 
+	readPath := configPath
 	data, err := os.ReadFile(configPath)
 	if os.IsNotExist(err) {
-		legacyPath, legacyErr := getLegacyStatePath()
+		legacyPath, legacyErr := getLegacyAppConfigPath()
 		if legacyErr != nil {
-			return &State{
-				ProjectSelections: make(map[string][]string),
-				ProjectFailures:   make(map[string][]string),
-			}, nil
+			return nil, "", nil
 		}
+		readPath = legacyPath
 		data, err = os.ReadFile(legacyPath)
 		if os.IsNotExist(err) {
-			return &State{
-				ProjectSelections: make(map[string][]string),
-				ProjectFailures:   make(map[string][]string),
-			}, nil
+			return nil, "", nil
 		}
 	}
 	if err != nil {
-		return nil, err
-	}
-
-	var state State
-	if err := json.Unmarshal(data, &state); err != nil {
-		return &State{
-			ProjectSelections: make(map[string][]string),
-			ProjectFailures:   make(map[string][]string),
-		}, nil
+		return nil, "", err
 	}
 
-	if state.ProjectSelections == nil {
-		state.ProjectSelections = make(map[string][]string)
-	}
-	if state.ProjectFailures == nil {
-		state.ProjectFailures = make(map[string][]string)
+	var raw rawAppSettings
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, "", fmt.Errorf("invalid app config at %s: %w", readPath, err)
 	}
-
-	return &state, nil
+	return &raw, SourceGlobal + ": " + readPath, nil
 }
 
-func SaveState(state *State) error {
-	configDir, err := getConfigDir()
-	if err != nil {
-		return err
+// apply layers raw on top of the current settings. Only values present in
+// raw override anything; keybinds override per action.
+func (s *AppSettings) apply(raw *rawAppSettings, source string) {
+	if s.Sources == nil {
+		s.Sources = map[string]string{}
 	}
 
-	if err := os.MkdirAll(configDir, 0755); err != nil {
-		return err
+	if strings.TrimSpace(raw.Theme) != "" {
+		s.Theme = strings.ToLower(strings.TrimSpace(raw.Theme))
+		s.Sources["theme"] = source
 	}
 
-	configPath := filepath.Join(configDir, stateFileName)
-
-	data, err := json.MarshalIndent(state, "", "  ")
-	if err != nil {
-		return err
+	for action, keys := range sanitizeKeybinds(raw.Keybinds) {
+		if s.Keybinds == nil {
+			s.Keybinds = map[string][]string{}
+		}
+		s.Keybinds[action] = keys
+		s.Sources["keybinds."+action] = source
 	}
 
-	return os.WriteFile(configPath, data, 0644)
-}
-
-func LoadAppSettings() (AppSettings, error) {
-	settings := DefaultAppSettings()
+	if raw.UI.Animations != nil {
+		s.UI.Animations = *raw.UI.Animations
+		s.Sources["ui.animations"] = source
+	}
+	if raw.UI.CompactHelp != nil {
+		s.UI.CompactHelp = *raw.UI.CompactHelp
+		s.Sources["ui.compact_help"] = source
+	}
+	if raw.UI.Modal != nil {
+		s.UI.Modal = *raw.UI.Modal
+		s.Sources["ui.modal"] = source
+	}
+	if raw.UI.Mouse != nil {
+		s.UI.Mouse = *raw.UI.Mouse
+		s.Sources["ui.mouse"] = source
+	}
+	if raw.UI.RestoreSession != nil {
+		s.UI.RestoreSession = *raw.UI.RestoreSession
+		s.Sources["ui.restore_session"] = source
+	}
 
-	configPath, err := GetAppConfigPath()
-	if err != nil {
-		return settings, nil
+	if command := cleanList(raw.Run.Command); len(command) > 0 {
+		s.Run.Command = command
+		s.Sources["run.command"] = source
+	}
+	if raw.Run.Args != nil {
+		s.Run.Args = cleanList(raw.Run.Args)
+		s.Sources["run.args"] = source
+	}
+	if command := strings.TrimSpace(raw.Open.Command); command != "" {
+		s.Open.Command = command
+		s.Sources["open.command"] = source
+	}
+	if paths := cleanList(raw.TestPaths); len(paths) > 0 {
+		s.TestPaths = paths
+		s.Sources["test_paths"] = source
+	}
+	if raw.Exclude != nil {
+		s.Exclude = cleanList(raw.Exclude)
+		s.Sources["exclude"] = source
+	}
+	if raw.Pinned != nil {
+		s.Pinned = cleanList(raw.Pinned)
+		s.Sources["pinned"] = source
+	}
+	if raw.Hidden != nil {
+		s.Hidden = cleanList(raw.Hidden)
+		s.Sources["hidden"] = source
 	}
 
-	data, err := os.ReadFile(configPath)
-	if os.IsNotExist(err) {
-		legacyPath, legacyErr := getLegacyAppConfigPath()
-		if legacyErr != nil {
-			return settings, nil
+	for name, rule := range raw.Sets {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
 		}
-		data, err = os.ReadFile(legacyPath)
-		if os.IsNotExist(err) {
-			return settings, nil
+		if s.Sets == nil {
+			s.Sets = map[string]SetRule{}
 		}
-	}
-	if err != nil {
-		return settings, err
+		s.Sets[name] = SetRule{
+			Paths: cleanList(rule.Paths),
+			Globs: cleanList(rule.Globs),
+			Tags:  cleanList(rule.Tags),
+		}
+		s.Sources["sets."+name] = source
 	}
 
-	var raw rawAppSettings
-	if err := json.Unmarshal(data, &raw); err != nil {
-		return settings, fmt.Errorf("invalid app config at %s: %w", configPath, err)
+	for name, action := range raw.Actions {
+		name = strings.ToLower(strings.TrimSpace(name))
+		command := strings.TrimSpace(action.Command)
+		if name == "" || command == "" {
+			continue
+		}
+		if s.Actions == nil {
+			s.Actions = map[string]CustomAction{}
+		}
+		s.Actions[name] = CustomAction{
+			Description: strings.TrimSpace(action.Description),
+			Command:     command,
+			Keys:        cleanList(action.Keys),
+		}
+		s.Sources["actions."+name] = source
 	}
 
-	if strings.TrimSpace(raw.Theme) != "" {
-		settings.Theme = strings.ToLower(strings.TrimSpace(raw.Theme))
+	for name, value := range raw.Colors {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if s.Colors == nil {
+			s.Colors = map[string]string{}
+		}
+		s.Colors[name] = strings.TrimSpace(value)
+		s.Sources["colors."+name] = source
 	}
+}
 
-	settings.Keybinds = sanitizeKeybinds(raw.Keybinds)
-
-	if raw.UI.Animations != nil {
-		settings.UI.Animations = *raw.UI.Animations
+func cleanList(values []string) []string {
+	if values == nil {
+		return nil
 	}
-	if raw.UI.CompactHelp != nil {
-		settings.UI.CompactHelp = *raw.UI.CompactHelp
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
 	}
-
-	return settings, nil
+	return out
 }
 
 func sanitizeKeybinds(in map[string][]string) map[string][]string {
@@ -252,6 +379,13 @@ func sanitizeKeys(keys []string) []string {
 }
 
 func normalizeKey(key string) string {
+	if parts := strings.Fields(key); len(parts) > 1 {
+		for i, part := range parts {
+			parts[i] = normalizeKey(part)
+		}
+		return strings.Join(parts, " ")
+	}
+
 	key = strings.ToLower(strings.TrimSpace(key))
 	if key == "" {
 		return ""
@@ -273,14 +407,6 @@ func normalizeKey(key string) string {
 	return key
 }
 
-func getLegacyStatePath() (string, error) {
-	dir, err := getLegacyConfigDir()
-	if err != nil {
-		return "", err
-	}
-	return filepath.Join(dir, stateFileName), nil
-}
-
 func getLegacyAppConfigPath() (string, error) {
 	dir, err := getLegacyConfigDir()
 	if err != nil {
@@ -288,59 +414,3 @@ func getLegacyAppConfigPath() (string, error) {
 	}
 	return filepath.Join(dir, appFileName), nil
 }
-
-func GetProjectSelections(projectDir string) ([]string, error) {
-	state, err := LoadState()
-	if err != nil {
-		return nil, err
-	}
-
-	selections, ok := state.ProjectSelections[projectDir]
-	if !ok {
-		return []string{}, nil
-	}
-
-	return selections, nil
-}
-
-func SaveProjectSelections(projectDir string, selections []string) error {
-	state, err := LoadState()
-	if err != nil {
-		state = &State{
-			ProjectSelections: make(map[string][]string),
-			ProjectFailures:   make(map[string][]string),
-		}
-	}
-
-	state.ProjectSelections[projectDir] = selections
-
-	return SaveState(state)
-}
-
-func GetProjectFailures(projectDir string) ([]string, error) {
-	state, err := LoadState()
-	if err != nil {
-		return nil, err
-	}
-
-	failures, ok := state.ProjectFailures[projectDir]
-	if !ok {
-		return []string{}, nil
-	}
-
-	return failures, nil
-}
-
-func SaveProjectFailures(projectDir string, failures []string) error {
-	state, err := LoadState()
-	if err != nil {
-		state = &State{
-			ProjectSelections: make(map[string][]string),
-			ProjectFailures:   make(map[string][]string),
-		}
-	}
-
-	state.ProjectFailures[projectDir] = failures
-
-	return SaveState(state)
-}
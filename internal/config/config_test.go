@@ -1,11 +1,14 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func prepareConfigPath(t *testing.T) string {
@@ -14,6 +17,7 @@ func prepareConfigPath(t *testing.T) string {
 	home := t.TempDir()
 	t.Setenv("HOME", home)
 	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(home, ".cache"))
 
 	configPath, err := GetAppConfigPath()
 	if err != nil {
@@ -22,12 +26,12 @@ func prepareConfigPath(t *testing.T) string {
 	return configPath
 }
 
-func TestLoadAppSettingsDefaultsWhenMissing(t *testing.T) {
+func TestLoadSettingsDefaultsWhenMissing(t *testing.T) {
 	_ = prepareConfigPath(t)
 
-	settings, err := LoadAppSettings()
+	settings, err := LoadSettings(t.TempDir(), FlagOverrides{})
 	if err != nil {
-		t.Fatalf("LoadAppSettings returned error: %v", err)
+		t.Fatalf("LoadSettings returned error: %v", err)
 	}
 
 	if settings.Theme != "default" {
@@ -52,7 +56,7 @@ func TestGetAppConfigPathUsesEztestDir(t *testing.T) {
 	}
 }
 
-func TestLoadAppSettingsReadsAndNormalizes(t *testing.T) {
+func TestLoadSettingsReadsAndNormalizesGlobalConfig(t *testing.T) {
 	configPath := prepareConfigPath(t)
 	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
 		t.Fatalf("failed to create config dir: %v", err)
@@ -74,9 +78,9 @@ func TestLoadAppSettingsReadsAndNormalizes(t *testing.T) {
 		t.Fatalf("failed to write config file: %v", err)
 	}
 
-	settings, err := LoadAppSettings()
+	settings, err := LoadSettings(t.TempDir(), FlagOverrides{})
 	if err != nil {
-		t.Fatalf("LoadAppSettings returned error: %v", err)
+		t.Fatalf("LoadSettings returned error: %v", err)
 	}
 
 	if settings.Theme != "catppucin" {
@@ -100,7 +104,7 @@ func TestLoadAppSettingsReadsAndNormalizes(t *testing.T) {
 	}
 }
 
-func TestLoadAppSettingsInvalidJSONFallsBack(t *testing.T) {
+func TestLoadSettingsInvalidGlobalConfigFallsBack(t *testing.T) {
 	configPath := prepareConfigPath(t)
 	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
 		t.Fatalf("failed to create config dir: %v", err)
@@ -109,7 +113,7 @@ func TestLoadAppSettingsInvalidJSONFallsBack(t *testing.T) {
 		t.Fatalf("failed to write config file: %v", err)
 	}
 
-	settings, err := LoadAppSettings()
+	settings, err := LoadSettings(t.TempDir(), FlagOverrides{})
 	if err == nil {
 		t.Fatalf("expected parse error for invalid config")
 	}
@@ -121,7 +125,7 @@ func TestLoadAppSettingsInvalidJSONFallsBack(t *testing.T) {
 	}
 }
 
-func TestLoadAppSettingsFallsBackToLegacyPath(t *testing.T) {
+func TestLoadSettingsFallsBackToLegacyGlobalPath(t *testing.T) {
 	home := t.TempDir()
 	t.Setenv("HOME", home)
 	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
@@ -136,9 +140,9 @@ func TestLoadAppSettingsFallsBackToLegacyPath(t *testing.T) {
 		t.Fatalf("failed to write legacy config file: %v", err)
 	}
 
-	settings, err := LoadAppSettings()
+	settings, err := LoadSettings(t.TempDir(), FlagOverrides{})
 	if err != nil {
-		t.Fatalf("LoadAppSettings returned error: %v", err)
+		t.Fatalf("LoadSettings returned error: %v", err)
 	}
 
 	if settings.Theme != "gruvbox" {
@@ -208,3 +212,300 @@ func TestSaveAndGetProjectFailures(t *testing.T) {
 		t.Fatalf("expected empty failures for unknown project, got %v", empty)
 	}
 }
+
+func TestProjectPinsStayUnsavedUntilChanged(t *testing.T) {
+	_ = prepareConfigPath(t)
+
+	projectDir := "/tmp/pinned_project"
+	if _, saved, err := GetProjectPinned(projectDir); err != nil || saved {
+		t.Fatalf("expected no saved pins for a new project, got saved=%v err=%v", saved, err)
+	}
+
+	if err := SaveProjectHidden(projectDir, nil); err != nil {
+		t.Fatalf("SaveProjectHidden returned error: %v", err)
+	}
+	hidden, saved, err := GetProjectHidden(projectDir)
+	if err != nil || !saved || len(hidden) != 0 {
+		t.Fatalf("expected an empty saved hidden list, got %v saved=%v err=%v", hidden, saved, err)
+	}
+	if _, saved, _ := GetProjectPinned(projectDir); saved {
+		t.Fatal("expected saving hidden files to leave pins unsaved")
+	}
+
+	input := []string{"test/foo_test.exs"}
+	if err := SaveProjectPinned(projectDir, input); err != nil {
+		t.Fatalf("SaveProjectPinned returned error: %v", err)
+	}
+	if got, saved, err := GetProjectPinned(projectDir); err != nil || !saved || !reflect.DeepEqual(got, input) {
+		t.Fatalf("unexpected pins: got %v saved=%v err=%v", got, saved, err)
+	}
+}
+
+func TestSaveAndGetProjectSession(t *testing.T) {
+	_ = prepareConfigPath(t)
+
+	projectDir := "/tmp/session_project"
+	if err := SaveProjectSort(projectDir, "recent"); err != nil {
+		t.Fatalf("SaveProjectSort returned error: %v", err)
+	}
+	session := Session{Query: "dir:test/my_app", Cursor: "test/my_app/accounts"}
+	if err := SaveProjectSession(projectDir, session); err != nil {
+		t.Fatalf("SaveProjectSession returned error: %v", err)
+	}
+	got, err := GetProjectSession(projectDir)
+	if err != nil {
+		t.Fatalf("GetProjectSession returned error: %v", err)
+	}
+	if !reflect.DeepEqual(got, session) {
+		t.Fatalf("unexpected session: got %+v want %+v", got, session)
+	}
+	if mode, _ := GetProjectSort(projectDir); mode != "recent" {
+		t.Fatalf("expected saving the session to keep the sort mode, got %q", mode)
+	}
+
+	var history []string
+	for i := 0; i < maxSearchHistory+5; i++ {
+		history = append(history, fmt.Sprintf("query %d", i))
+	}
+	if err := SaveProjectSearchHistory(projectDir, history); err != nil {
+		t.Fatalf("SaveProjectSearchHistory returned error: %v", err)
+	}
+	saved, err := GetProjectSearchHistory(projectDir)
+	if err != nil {
+		t.Fatalf("GetProjectSearchHistory returned error: %v", err)
+	}
+	if len(saved) != maxSearchHistory || saved[len(saved)-1] != history[len(history)-1] {
+		t.Fatalf("expected the %d most recent queries, got %d ending in %q", maxSearchHistory, len(saved), saved[len(saved)-1])
+	}
+}
+
+func TestLoadProjectStateBacksUpCorruptFile(t *testing.T) {
+	_ = prepareConfigPath(t)
+	projectDir := "/tmp/corrupt_project"
+
+	statePath, err := GetProjectStatePath(projectDir)
+	if err != nil {
+		t.Fatalf("GetProjectStatePath returned error: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(statePath), 0755); err != nil {
+		t.Fatalf("failed to create state dir: %v", err)
+	}
+	if err := os.WriteFile(statePath, []byte(`{"selections":`), 0644); err != nil {
+		t.Fatalf("failed to write state file: %v", err)
+	}
+
+	var warnings []string
+	original := warnf
+	warnf = func(format string, args ...any) {
+		warnings = append(warnings, format)
+	}
+	t.Cleanup(func() {
+		warnf = original
+	})
+
+	state, err := LoadProjectState(projectDir)
+	if err != nil {
+		t.Fatalf("LoadProjectState returned error: %v", err)
+	}
+	if len(state.Selections) != 0 {
+		t.Fatalf("expected empty state, got %+v", state)
+	}
+	if len(warnings) != 1 {
+		t.Fatalf("expected one warning about the corrupt file, got %v", warnings)
+	}
+
+	backups, _ := filepath.Glob(statePath + ".corrupt-*")
+	if len(backups) != 1 {
+		t.Fatalf("expected corrupt state to be backed up, found %v", backups)
+	}
+	if _, err := os.Stat(statePath); !os.IsNotExist(err) {
+		t.Fatalf("expected corrupt state file to be moved aside, stat err = %v", err)
+	}
+}
+
+func TestConcurrentUpdatesDoNotLoseChanges(t *testing.T) {
+	_ = prepareConfigPath(t)
+	projectDir := "/tmp/busy_project"
+
+	const writers = 20
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := UpdateProjectState(projectDir, func(state *ProjectState) error {
+				state.Selections = append(state.Selections, fmt.Sprintf("test/file_%d_test.exs", i))
+				return nil
+			})
+			if err != nil {
+				t.Errorf("UpdateProjectState returned error: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	selections, err := GetProjectSelections(projectDir)
+	if err != nil {
+		t.Fatalf("GetProjectSelections returned error: %v", err)
+	}
+	if got := len(selections); got != writers {
+		t.Fatalf("expected %d selections after concurrent updates, got %d", writers, got)
+	}
+}
+
+func TestProjectStateIsStoredPerProject(t *testing.T) {
+	_ = prepareConfigPath(t)
+
+	if err := SaveProjectSelections("/tmp/one", []string{"test/one_test.exs"}); err != nil {
+		t.Fatalf("SaveProjectSelections returned error: %v", err)
+	}
+	if err := SaveProjectSelections("/tmp/two", []string{"test/two_test.exs"}); err != nil {
+		t.Fatalf("SaveProjectSelections returned error: %v", err)
+	}
+
+	onePath, _ := GetProjectStatePath("/tmp/one")
+	twoPath, _ := GetProjectStatePath("/tmp/two")
+	if onePath == twoPath {
+		t.Fatalf("expected distinct state files per project, both were %q", onePath)
+	}
+
+	data, err := os.ReadFile(onePath)
+	if err != nil {
+		t.Fatalf("failed to read project state: %v", err)
+	}
+	if !strings.Contains(string(data), `"version": 1`) {
+		t.Fatalf("expected schema version in project state, got %s", data)
+	}
+	if strings.Contains(string(data), "two_test") {
+		t.Fatalf("project state leaked another project's data: %s", data)
+	}
+
+	projects, err := ListProjects()
+	if err != nil {
+		t.Fatalf("ListProjects returned error: %v", err)
+	}
+	if len(projects) != 2 || projects[0].Root != "/tmp/one" || projects[1].Root != "/tmp/two" {
+		t.Fatalf("unexpected project list: %+v", projects)
+	}
+
+	deleted, err := DeleteProjectState("/tmp/one")
+	if err != nil || !deleted {
+		t.Fatalf("DeleteProjectState = %v, %v; want true, nil", deleted, err)
+	}
+	if selections, _ := GetProjectSelections("/tmp/one"); len(selections) != 0 {
+		t.Fatalf("expected deleted project to have no selections, got %v", selections)
+	}
+}
+
+func TestMigratesGlobalStateFile(t *testing.T) {
+	configPath := prepareConfigPath(t)
+	statePath := filepath.Join(filepath.Dir(configPath), "state.json")
+	if err := os.MkdirAll(filepath.Dir(statePath), 0755); err != nil {
+		t.Fatalf("failed to create config dir: %v", err)
+	}
+
+	stateJSON := `{"project_selections":{"/tmp/a":["test/a_test.exs"]},"project_failures":{"/tmp/b":["test/b_test.exs"]}}`
+	if err := os.WriteFile(statePath, []byte(stateJSON), 0644); err != nil {
+		t.Fatalf("failed to write state file: %v", err)
+	}
+
+	if got, _ := GetProjectSelections("/tmp/a"); !reflect.DeepEqual(got, []string{"test/a_test.exs"}) {
+		t.Fatalf("unexpected migrated selections: %v", got)
+	}
+	if got, _ := GetProjectFailures("/tmp/b"); !reflect.DeepEqual(got, []string{"test/b_test.exs"}) {
+		t.Fatalf("unexpected migrated failures: %v", got)
+	}
+	if _, err := os.Stat(statePath); !os.IsNotExist(err) {
+		t.Fatalf("expected global state file to be retired after migration, stat err = %v", err)
+	}
+	if _, err := os.Stat(statePath + ".migrated"); err != nil {
+		t.Fatalf("expected migrated backup of global state: %v", err)
+	}
+}
+
+func TestSaveProjectRunRecordsHistoryAndFailures(t *testing.T) {
+	_ = prepareConfigPath(t)
+
+	projectDir := "/tmp/history_project"
+	for i := 0; i < maxRunHistory+5; i++ {
+		record := RunRecord{
+			StartedAt: time.Unix(int64(i), 0).UTC(),
+			Files:     []string{fmt.Sprintf("test/%d_test.exs", i)},
+		}
+		if err := SaveProjectRun(projectDir, record, nil); err != nil {
+			t.Fatalf("SaveProjectRun returned error: %v", err)
+		}
+	}
+	last := RunRecord{
+		StartedAt: time.Unix(1000, 0).UTC(),
+		Files:     []string{"test/a_test.exs"},
+		Failed:    []string{"test/a_test.exs"},
+		ExitCode:  2,
+	}
+	messages := map[string]string{
+		"test/a_test.exs":      "1) test works (ATest)",
+		"test/passed_test.exs": "ignored because the file did not fail",
+	}
+	if err := SaveProjectRun(projectDir, last, messages); err != nil {
+		t.Fatalf("SaveProjectRun returned error: %v", err)
+	}
+
+	history, err := GetProjectHistory(projectDir)
+	if err != nil {
+		t.Fatalf("GetProjectHistory returned error: %v", err)
+	}
+	if len(history) != maxRunHistory {
+		t.Fatalf("expected history capped at %d, got %d", maxRunHistory, len(history))
+	}
+	if got := history[len(history)-1]; !reflect.DeepEqual(got, last) {
+		t.Fatalf("unexpected newest record: got %+v want %+v", got, last)
+	}
+	if got := history[0].Files[0]; got != "test/6_test.exs" {
+		t.Fatalf("expected oldest records to be dropped, first is %s", got)
+	}
+
+	failures, err := GetProjectFailures(projectDir)
+	if err != nil {
+		t.Fatalf("GetProjectFailures returned error: %v", err)
+	}
+	if !reflect.DeepEqual(failures, last.Failed) {
+		t.Fatalf("unexpected failures: got %v want %v", failures, last.Failed)
+	}
+
+	saved, err := GetProjectFailureMessages(projectDir)
+	if err != nil {
+		t.Fatalf("GetProjectFailureMessages returned error: %v", err)
+	}
+	if want := map[string]string{"test/a_test.exs": "1) test works (ATest)"}; !reflect.DeepEqual(saved, want) {
+		t.Fatalf("unexpected failure messages: got %v want %v", saved, want)
+	}
+}
+
+func TestDeleteProjectStateRemovesCaches(t *testing.T) {
+	_ = prepareConfigPath(t)
+	projectDir := "/tmp/cached_project"
+
+	if err := SaveProjectSelections(projectDir, []string{"test/a_test.exs"}); err != nil {
+		t.Fatalf("SaveProjectSelections returned error: %v", err)
+	}
+	cachePath, err := GetProjectCachePath(projectDir, "outline")
+	if err != nil {
+		t.Fatalf("GetProjectCachePath returned error: %v", err)
+	}
+	if want := filepath.Join(os.Getenv("XDG_CACHE_HOME"), "eztest"); filepath.Dir(cachePath) != want {
+		t.Fatalf("expected cache under %s, got %s", want, cachePath)
+	}
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err != nil {
+		t.Fatalf("MkdirAll returned error: %v", err)
+	}
+	if err := os.WriteFile(cachePath, []byte("{}"), 0644); err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+
+	if _, err := DeleteProjectState(projectDir); err != nil {
+		t.Fatalf("DeleteProjectState returned error: %v", err)
+	}
+	if _, err := os.Stat(cachePath); !os.IsNotExist(err) {
+		t.Fatalf("expected cache to be removed, stat err = %v", err)
+	}
+}
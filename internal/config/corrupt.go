@@ -0,0 +1,22 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// warnf reports a problem with stored files that eztest recovered from.
+var warnf = func(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "Warning: "+format+"\n", args...)
+}
+
+// backupCorruptFile moves an unreadable file aside so it can be inspected
+// later instead of being silently overwritten.
+func backupCorruptFile(path string) (string, error) {
+	backup := fmt.Sprintf("%s.corrupt-%s", path, time.Now().Format("20060102T150405"))
+	if err := os.Rename(path, backup); err != nil {
+		return "", err
+	}
+	return backup, nil
+}
@@ -0,0 +1,217 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Source labels used in AppSettings.Sources.
+const (
+	SourceDefault = "default"
+	SourceGlobal  = "global"
+	SourceProject = "project"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// ProjectConfigNames are the repository-local config files checked at the
+// project root, in order of preference.
+var ProjectConfigNames = []string{".eztest.json", filepath.Join(".eztest", "config.json")}
+
+// FlagOverrides carries settings passed on the command line. Zero values
+// leave the lower layers untouched.
+type FlagOverrides struct {
+	Theme   string
+	RunArgs []string
+	Exclude []string
+}
+
+// LoadSettings merges every config layer for a project:
+// built-in defaults < global config < project config < environment < flags.
+// A layer that fails to parse is skipped and reported in the returned error;
+// the remaining layers still apply.
+func LoadSettings(projectDir string, flags FlagOverrides) (AppSettings, error) {
+	settings := DefaultAppSettings()
+	var errs []error
+
+	if raw, source, err := readGlobalSettings(); err != nil {
+		errs = append(errs, err)
+	} else if raw != nil {
+		settings.apply(raw, source)
+	}
+
+	if raw, source, err := readProjectSettings(projectDir); err != nil {
+		errs = append(errs, err)
+	} else if raw != nil {
+		settings.apply(raw, source)
+	}
+
+	raw, envErrs := envSettings(os.Getenv)
+	errs = append(errs, envErrs...)
+	settings.applyLabeled(raw, SourceEnv)
+
+	settings.applyLabeled(flagSettings(flags), SourceFlag)
+
+	return settings, errors.Join(errs...)
+}
+
+// FindProjectConfig returns the path of the project config file under
+// projectDir, or "" when there is none.
+func FindProjectConfig(projectDir string) string {
+	if projectDir == "" {
+		return ""
+	}
+	for _, name := range ProjectConfigNames {
+		path := filepath.Join(projectDir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+func readProjectSettings(projectDir string) (*rawAppSettings, string, error) {
+	path := FindProjectConfig(projectDir)
+	if path == "" {
+		return nil, "", nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var raw rawAppSettings
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, "", fmt.Errorf("invalid project config at %s: %w", path, err)
+	}
+	return &raw, SourceProject + ": " + path, nil
+}
+
+// labeledSettings pairs a partial settings layer with the per-value source
+// label (the env var or flag name) used to explain where it came from.
+type labeledSettings struct {
+	raw    rawAppSettings
+	labels map[string]string
+}
+
+func (s *AppSettings) applyLabeled(layer labeledSettings, kind string) {
+	s.apply(&layer.raw, kind)
+	for name, label := range layer.labels {
+		if s.Sources[name] == kind {
+			s.Sources[name] = kind + ": " + label
+		}
+	}
+}
+
+func envSettings(getenv func(string) string) (labeledSettings, []error) {
+	layer := labeledSettings{labels: map[string]string{}}
+	var errs []error
+
+	set := func(name, env string, fn func(string) error) {
+		value := strings.TrimSpace(getenv(env))
+		if value == "" {
+			return
+		}
+		if err := fn(value); err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s: %w", env, err))
+			return
+		}
+		layer.labels[name] = env
+	}
+
+	set("theme", "EZTEST_THEME", func(v string) error {
+		layer.raw.Theme = v
+		return nil
+	})
+	set("ui.animations", "EZTEST_ANIMATIONS", func(v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return err
+		}
+		layer.raw.UI.Animations = &b
+		return nil
+	})
+	set("ui.compact_help", "EZTEST_COMPACT_HELP", func(v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return err
+		}
+		layer.raw.UI.CompactHelp = &b
+		return nil
+	})
+	set("ui.modal", "EZTEST_MODAL", func(v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return err
+		}
+		layer.raw.UI.Modal = &b
+		return nil
+	})
+	set("ui.mouse", "EZTEST_MOUSE", func(v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return err
+		}
+		layer.raw.UI.Mouse = &b
+		return nil
+	})
+	set("ui.restore_session", "EZTEST_RESTORE_SESSION", func(v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return err
+		}
+		layer.raw.UI.RestoreSession = &b
+		return nil
+	})
+	set("run.command", "EZTEST_RUN_COMMAND", func(v string) error {
+		layer.raw.Run.Command = strings.Fields(v)
+		return nil
+	})
+	set("run.args", "EZTEST_RUN_ARGS", func(v string) error {
+		layer.raw.Run.Args = strings.Fields(v)
+		return nil
+	})
+	set("open.command", "EZTEST_OPEN_COMMAND", func(v string) error {
+		layer.raw.Open.Command = v
+		return nil
+	})
+	set("test_paths", "EZTEST_TEST_PATHS", func(v string) error {
+		layer.raw.TestPaths = splitList(v)
+		return nil
+	})
+	set("exclude", "EZTEST_EXCLUDE", func(v string) error {
+		layer.raw.Exclude = splitList(v)
+		return nil
+	})
+
+	return layer, errs
+}
+
+func flagSettings(flags FlagOverrides) labeledSettings {
+	layer := labeledSettings{labels: map[string]string{}}
+
+	if strings.TrimSpace(flags.Theme) != "" {
+		layer.raw.Theme = flags.Theme
+		layer.labels["theme"] = "--theme"
+	}
+	if len(flags.RunArgs) > 0 {
+		layer.raw.Run.Args = flags.RunArgs
+		layer.labels["run.args"] = "--args"
+	}
+	if len(flags.Exclude) > 0 {
+		layer.raw.Exclude = flags.Exclude
+		layer.labels["exclude"] = "--exclude"
+	}
+
+	return layer
+}
+
+func splitList(value string) []string {
+	return cleanList(strings.Split(value, ","))
+}
@@ -0,0 +1,142 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, contents string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create dir for %s: %v", path, err)
+	}
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func TestLoadSettingsLayersInOrder(t *testing.T) {
+	configPath := prepareConfigPath(t)
+	projectDir := t.TempDir()
+
+	writeFile(t, configPath, `{
+  "theme": "gruvbox",
+  "keybinds": {"up": ["k"], "down": ["j"]},
+  "ui": {"animations": false},
+  "run": {"args": ["--trace"]}
+}`)
+	writeFile(t, filepath.Join(projectDir, ".eztest.json"), `{
+  "theme": "catppuccin",
+  "keybinds": {"up": ["ctrl+p"]},
+  "exclude": ["test/browser/**"]
+}`)
+	t.Setenv("EZTEST_COMPACT_HELP", "true")
+	t.Setenv("EZTEST_THEME", "default")
+
+	settings, err := LoadSettings(projectDir, FlagOverrides{RunArgs: []string{"--seed", "0"}})
+	if err != nil {
+		t.Fatalf("LoadSettings returned error: %v", err)
+	}
+
+	if settings.Theme != "default" {
+		t.Fatalf("expected env to override theme, got %q", settings.Theme)
+	}
+	if got := settings.Sources["theme"]; got != "env: EZTEST_THEME" {
+		t.Fatalf("unexpected theme source %q", got)
+	}
+	if got, want := settings.Keybinds["up"], []string{"ctrl+p"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected project keybind to win, got %v", got)
+	}
+	if got, want := settings.Keybinds["down"], []string{"j"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected global keybind to survive, got %v", got)
+	}
+	if settings.UI.Animations {
+		t.Fatalf("expected global animations=false to apply")
+	}
+	if !strings.HasPrefix(settings.Sources["ui.animations"], "global: ") {
+		t.Fatalf("unexpected animations source %q", settings.Sources["ui.animations"])
+	}
+	if !settings.UI.CompactHelp {
+		t.Fatalf("expected env compact help to apply")
+	}
+	if got, want := settings.Run.Args, []string{"--seed", "0"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected flag run args to win, got %v", got)
+	}
+	if got := settings.Sources["run.args"]; got != "flag: --args" {
+		t.Fatalf("unexpected run.args source %q", got)
+	}
+	if got, want := settings.Exclude, []string{"test/browser/**"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected exclude: got %v want %v", got, want)
+	}
+	if got := settings.Sources["run.command"]; got != SourceDefault {
+		t.Fatalf("expected run.command to come from defaults, got %q", got)
+	}
+}
+
+func TestLoadSettingsReadsNestedProjectConfig(t *testing.T) {
+	_ = prepareConfigPath(t)
+	projectDir := t.TempDir()
+	writeFile(t, filepath.Join(projectDir, ".eztest", "config.json"), `{"test_paths": ["test/unit"]}`)
+
+	settings, err := LoadSettings(projectDir, FlagOverrides{})
+	if err != nil {
+		t.Fatalf("LoadSettings returned error: %v", err)
+	}
+	if got, want := settings.TestPaths, []string{"test/unit"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected test paths: got %v want %v", got, want)
+	}
+}
+
+func TestLoadSettingsSkipsBrokenLayers(t *testing.T) {
+	_ = prepareConfigPath(t)
+	projectDir := t.TempDir()
+	writeFile(t, filepath.Join(projectDir, ".eztest.json"), `{"theme":`)
+	t.Setenv("EZTEST_ANIMATIONS", "sometimes")
+	t.Setenv("EZTEST_EXCLUDE", "test/a/**, test/b/**")
+
+	settings, err := LoadSettings(projectDir, FlagOverrides{})
+	if err == nil {
+		t.Fatalf("expected errors for broken project config and env value")
+	}
+	if !strings.Contains(err.Error(), "EZTEST_ANIMATIONS") {
+		t.Fatalf("expected env error to be reported, got %v", err)
+	}
+	if !settings.UI.Animations {
+		t.Fatalf("expected invalid env bool to be ignored")
+	}
+	if got, want := settings.Exclude, []string{"test/a/**", "test/b/**"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected exclude from env: got %v want %v", got, want)
+	}
+}
+
+func TestLoadSettingsMergesCustomActionsByName(t *testing.T) {
+	configPath := prepareConfigPath(t)
+	projectDir := t.TempDir()
+
+	writeFile(t, configPath, `{"actions": {
+  "credo": {"description": "credo", "command": "mix credo {files}"},
+  "format": {"command": "mix format {files}", "keys": ["alt+f"]}
+}}`)
+	writeFile(t, filepath.Join(projectDir, ".eztest.json"), `{"actions": {
+  "Credo": {"description": "strict credo", "command": " mix credo --strict {files} ", "keys": ["space c"]},
+  "broken": {"description": "no command"}
+}}`)
+
+	settings, err := LoadSettings(projectDir, FlagOverrides{})
+	if err != nil {
+		t.Fatalf("LoadSettings returned error: %v", err)
+	}
+	want := map[string]CustomAction{
+		"credo":  {Description: "strict credo", Command: "mix credo --strict {files}", Keys: []string{"space c"}},
+		"format": {Command: "mix format {files}", Keys: []string{"alt+f"}},
+	}
+	if !reflect.DeepEqual(settings.Actions, want) {
+		t.Fatalf("unexpected actions: got %+v want %+v", settings.Actions, want)
+	}
+	if !strings.HasPrefix(settings.Sources["actions.credo"], "project: ") {
+		t.Fatalf("unexpected credo source %q", settings.Sources["actions.credo"])
+	}
+}
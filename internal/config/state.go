@@ -0,0 +1,617 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/samrobinsonsauce/eztest/internal/fsutil"
+)
+
+const (
+	projectStateVersion = 1
+	projectsDirName     = "projects"
+	migratedSuffix      = ".migrated"
+	// maxRunHistory caps how many past runs are kept per project.
+	maxRunHistory = 50
+	// maxSearchHistory caps how many past search queries are kept.
+	maxSearchHistory = 50
+)
+
+// ProjectState is everything ezt remembers about a single project. Each
+// project is stored in its own file so saves only rewrite that project.
+type ProjectState struct {
+	Version    int       `json:"version"`
+	Root       string    `json:"root"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	Selections []string  `json:"selections"`
+	Failures   []string  `json:"failures,omitempty"`
+	// FailureMessages holds the ExUnit output for each file that failed in
+	// the most recent run.
+	FailureMessages map[string]string `json:"failure_messages,omitempty"`
+	// Sets holds named selections saved from the TUI.
+	Sets map[string][]string `json:"sets,omitempty"`
+	// History lists past test runs, oldest first.
+	History []RunRecord `json:"history,omitempty"`
+	// TreeView remembers whether the TUI list was last shown as a tree, and
+	// Collapsed the tree directories that were folded.
+	TreeView  bool     `json:"tree_view,omitempty"`
+	Collapsed []string `json:"collapsed,omitempty"`
+	// SortMode is the order the TUI list was last sorted in.
+	SortMode string `json:"sort_mode,omitempty"`
+	// Pinned and Hidden list the files pinned to the top of the TUI list
+	// and hidden from it. They are nil until first changed in the TUI, so
+	// the config's defaults apply.
+	Pinned *[]string `json:"pinned,omitempty"`
+	Hidden *[]string `json:"hidden,omitempty"`
+	// Query and Cursor are the search query and the file or directory under
+	// the cursor when the TUI was last closed.
+	Query  string `json:"query,omitempty"`
+	Cursor string `json:"cursor,omitempty"`
+	// SearchHistory lists past search queries, oldest first.
+	SearchHistory []string `json:"search_history,omitempty"`
+}
+
+// RunRecord describes a single test run started by ezt.
+type RunRecord struct {
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration"`
+	Files     []string      `json:"files"`
+	Failed    []string      `json:"failed,omitempty"`
+	ExitCode  int           `json:"exit_code"`
+}
+
+// legacyState is the pre-v1 single-file layout keyed by absolute project path.
+type legacyState struct {
+	ProjectSelections map[string][]string `json:"project_selections"`
+	ProjectFailures   map[string][]string `json:"project_failures,omitempty"`
+}
+
+func newProjectState(root string) *ProjectState {
+	return &ProjectState{
+		Version:    projectStateVersion,
+		Root:       root,
+		Selections: []string{},
+		Failures:   []string{},
+	}
+}
+
+// GetStateDir returns the directory holding per-project state. It honours
+// XDG_STATE_HOME and otherwise lives next to the app config.
+func GetStateDir() (string, error) {
+	if dir := strings.TrimSpace(os.Getenv("XDG_STATE_HOME")); dir != "" {
+		return filepath.Join(dir, configDirName), nil
+	}
+	return getConfigDir()
+}
+
+func getProjectsDir() (string, error) {
+	dir, err := GetStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, projectsDirName), nil
+}
+
+func projectKey(root string) string {
+	sum := sha256.Sum256([]byte(filepath.Clean(root)))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// GetCacheDir returns the directory for data ezt can rebuild at any time,
+// such as the test content index. It honours XDG_CACHE_HOME.
+func GetCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, configDirName), nil
+}
+
+// GetProjectCachePath returns the cache file of the given kind for a
+// project root, e.g. kind "outline".
+func GetProjectCachePath(root, kind string) (string, error) {
+	dir, err := GetCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, projectKey(root)+"-"+kind+".json"), nil
+}
+
+// GetProjectStatePath returns the state file used for the given project root.
+func GetProjectStatePath(root string) (string, error) {
+	dir, err := getProjectsDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, projectKey(root)+".json"), nil
+}
+
+// LoadProjectState reads the state for a project, migrating any legacy
+// state.json data first. A missing file yields empty state.
+func LoadProjectState(root string) (*ProjectState, error) {
+	if err := migrateLegacyState(); err != nil {
+		warnf("could not migrate legacy state: %v", err)
+	}
+
+	path, err := GetProjectStatePath(root)
+	if err != nil {
+		return newProjectState(root), nil
+	}
+	return readProjectState(path, root)
+}
+
+func readProjectState(path, root string) (*ProjectState, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return newProjectState(root), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	state := newProjectState(root)
+	if err := json.Unmarshal(data, state); err != nil {
+		backup, backupErr := backupCorruptFile(path)
+		if backupErr != nil {
+			return nil, fmt.Errorf("state file %s is corrupt (%v) and could not be backed up: %w", path, err, backupErr)
+		}
+		warnf("state file %s is corrupt (%v); moved it to %s and starting fresh", path, err, backup)
+		return newProjectState(root), nil
+	}
+
+	if state.Version > projectStateVersion {
+		return nil, fmt.Errorf("state file %s has schema version %d; this ezt supports up to %d", path, state.Version, projectStateVersion)
+	}
+	upgradeProjectState(state)
+
+	if state.Selections == nil {
+		state.Selections = []string{}
+	}
+	if state.Failures == nil {
+		state.Failures = []string{}
+	}
+
+	return state, nil
+}
+
+// upgradeProjectState brings an older on-disk schema up to the current one.
+func upgradeProjectState(state *ProjectState) {
+	if state.Version < 1 {
+		state.Version = 1
+	}
+}
+
+// UpdateProjectState runs fn against the latest saved project state and
+// writes the result, holding the project's lock for the whole cycle.
+func UpdateProjectState(root string, fn func(*ProjectState) error) error {
+	if err := migrateLegacyState(); err != nil {
+		warnf("could not migrate legacy state: %v", err)
+	}
+
+	path, err := GetProjectStatePath(root)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	unlock, err := lockFile(strings.TrimSuffix(path, ".json") + ".lock")
+	if err != nil {
+		return fmt.Errorf("could not lock state file: %w", err)
+	}
+	defer unlock()
+
+	state, err := readProjectState(path, root)
+	if err != nil {
+		return err
+	}
+	if err := fn(state); err != nil {
+		return err
+	}
+
+	return writeProjectState(path, state)
+}
+
+func writeProjectState(path string, state *ProjectState) error {
+	state.Version = projectStateVersion
+	state.UpdatedAt = time.Now().UTC()
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(path, data, 0644)
+}
+
+// ListProjects returns the state of every project ezt knows about, sorted
+// by root path.
+func ListProjects() ([]ProjectState, error) {
+	if err := migrateLegacyState(); err != nil {
+		warnf("could not migrate legacy state: %v", err)
+	}
+
+	dir, err := getProjectsDir()
+	if err != nil {
+		return nil, err
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	projects := make([]ProjectState, 0, len(paths))
+	for _, path := range paths {
+		state, err := readProjectState(path, "")
+		if err != nil {
+			warnf("skipping %s: %v", path, err)
+			continue
+		}
+		if state.Root == "" {
+			continue
+		}
+		projects = append(projects, *state)
+	}
+
+	sort.Slice(projects, func(i, j int) bool {
+		return projects[i].Root < projects[j].Root
+	})
+	return projects, nil
+}
+
+// DeleteProjectState removes everything stored for a project. It reports
+// whether any state existed. The state file is removed under the project's
+// lock so it can't race a save; the lock file itself stays, since removing
+// it would let two later saves lock different files.
+func DeleteProjectState(root string) (bool, error) {
+	path, err := GetProjectStatePath(root)
+	if err != nil {
+		return false, err
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return false, nil
+	}
+
+	unlock, err := lockFile(strings.TrimSuffix(path, ".json") + ".lock")
+	if err != nil {
+		return false, fmt.Errorf("could not lock state file: %w", err)
+	}
+	err = os.Remove(path)
+	unlock()
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if dir, err := GetCacheDir(); err == nil {
+		caches, _ := filepath.Glob(filepath.Join(dir, projectKey(root)+"-*.json"))
+		for _, cache := range caches {
+			_ = os.Remove(cache)
+		}
+	}
+	return true, nil
+}
+
+// migrateLegacyState splits the old single state.json files (from both the
+// eztest and legacy ezt config dirs) into per-project files. Projects that
+// already have a per-project file are left alone. Migrated files are renamed
+// with a .migrated suffix so the work only happens once.
+func migrateLegacyState() error {
+	var sources []string
+	if dir, err := getConfigDir(); err == nil {
+		sources = append(sources, filepath.Join(dir, stateFileName))
+	}
+	if dir, err := getLegacyConfigDir(); err == nil {
+		sources = append(sources, filepath.Join(dir, stateFileName))
+	}
+
+	for _, source := range sources {
+		if _, err := os.Stat(source); err != nil {
+			continue
+		}
+		if err := migrateLegacyStateFile(source); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func migrateLegacyStateFile(source string) error {
+	projectsDir, err := getProjectsDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(projectsDir, 0755); err != nil {
+		return err
+	}
+
+	unlock, err := lockFile(filepath.Join(projectsDir, "migrate.lock"))
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	data, err := os.ReadFile(source)
+	if os.IsNotExist(err) {
+		// Another process migrated it while we waited for the lock.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var legacy legacyState
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		backup, backupErr := backupCorruptFile(source)
+		if backupErr != nil {
+			return backupErr
+		}
+		warnf("legacy state file %s is corrupt (%v); moved it to %s", source, err, backup)
+		return nil
+	}
+
+	roots := map[string]struct{}{}
+	for root := range legacy.ProjectSelections {
+		roots[root] = struct{}{}
+	}
+	for root := range legacy.ProjectFailures {
+		roots[root] = struct{}{}
+	}
+
+	for root := range roots {
+		path := filepath.Join(projectsDir, projectKey(root)+".json")
+		if _, err := os.Stat(path); err == nil {
+			continue
+		}
+
+		state := newProjectState(root)
+		if selections, ok := legacy.ProjectSelections[root]; ok && selections != nil {
+			state.Selections = selections
+		}
+		if failures, ok := legacy.ProjectFailures[root]; ok && failures != nil {
+			state.Failures = failures
+		}
+		if err := writeProjectState(path, state); err != nil {
+			return err
+		}
+	}
+
+	return os.Rename(source, source+migratedSuffix)
+}
+
+func GetProjectSelections(projectDir string) ([]string, error) {
+	state, err := LoadProjectState(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	return state.Selections, nil
+}
+
+func SaveProjectSelections(projectDir string, selections []string) error {
+	return UpdateProjectState(projectDir, func(state *ProjectState) error {
+		state.Selections = selections
+		return nil
+	})
+}
+
+func GetProjectFailures(projectDir string) ([]string, error) {
+	state, err := LoadProjectState(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	return state.Failures, nil
+}
+
+func SaveProjectFailures(projectDir string, failures []string) error {
+	return UpdateProjectState(projectDir, func(state *ProjectState) error {
+		state.Failures = failures
+		return nil
+	})
+}
+
+func GetProjectSets(projectDir string) (map[string][]string, error) {
+	state, err := LoadProjectState(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	if state.Sets == nil {
+		return map[string][]string{}, nil
+	}
+	return state.Sets, nil
+}
+
+// SaveProjectSet stores files under name, replacing any existing set with
+// that name. An empty file list deletes the set.
+func SaveProjectSet(projectDir, name string, files []string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("set name cannot be empty")
+	}
+
+	return UpdateProjectState(projectDir, func(state *ProjectState) error {
+		if len(files) == 0 {
+			delete(state.Sets, name)
+			return nil
+		}
+		if state.Sets == nil {
+			state.Sets = map[string][]string{}
+		}
+		state.Sets[name] = files
+		return nil
+	})
+}
+
+// GetProjectView returns whether the TUI should open in tree view and which
+// directories are collapsed.
+func GetProjectView(projectDir string) (bool, []string, error) {
+	state, err := LoadProjectState(projectDir)
+	if err != nil {
+		return false, nil, err
+	}
+	return state.TreeView, state.Collapsed, nil
+}
+
+func SaveProjectView(projectDir string, treeView bool, collapsed []string) error {
+	return UpdateProjectState(projectDir, func(state *ProjectState) error {
+		state.TreeView = treeView
+		state.Collapsed = collapsed
+		return nil
+	})
+}
+
+// GetProjectSort returns the sort mode the TUI list last used, or "".
+func GetProjectSort(projectDir string) (string, error) {
+	state, err := LoadProjectState(projectDir)
+	if err != nil {
+		return "", err
+	}
+	return state.SortMode, nil
+}
+
+func SaveProjectSort(projectDir, mode string) error {
+	return UpdateProjectState(projectDir, func(state *ProjectState) error {
+		state.SortMode = mode
+		return nil
+	})
+}
+
+// Session is where the TUI was left in a project. The view and sort mode
+// are saved separately as they change.
+type Session struct {
+	Query  string
+	Cursor string
+}
+
+// GetProjectSession returns the TUI session last saved for a project.
+func GetProjectSession(projectDir string) (Session, error) {
+	state, err := LoadProjectState(projectDir)
+	if err != nil {
+		return Session{}, err
+	}
+	return Session{Query: state.Query, Cursor: state.Cursor}, nil
+}
+
+func SaveProjectSession(projectDir string, session Session) error {
+	return UpdateProjectState(projectDir, func(state *ProjectState) error {
+		state.Query = session.Query
+		state.Cursor = session.Cursor
+		return nil
+	})
+}
+
+// GetProjectSearchHistory returns the project's past search queries, oldest
+// first.
+func GetProjectSearchHistory(projectDir string) ([]string, error) {
+	state, err := LoadProjectState(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	return state.SearchHistory, nil
+}
+
+// SaveProjectSearchHistory stores the search history, keeping only the most
+// recent queries.
+func SaveProjectSearchHistory(projectDir string, history []string) error {
+	if excess := len(history) - maxSearchHistory; excess > 0 {
+		history = history[excess:]
+	}
+	return UpdateProjectState(projectDir, func(state *ProjectState) error {
+		state.SearchHistory = history
+		return nil
+	})
+}
+
+// GetProjectPinned returns the files pinned in the TUI. saved is false when
+// the project never changed its pins, so the config's defaults apply.
+func GetProjectPinned(projectDir string) (files []string, saved bool, err error) {
+	state, err := LoadProjectState(projectDir)
+	if err != nil || state.Pinned == nil {
+		return nil, false, err
+	}
+	return *state.Pinned, true, nil
+}
+
+func SaveProjectPinned(projectDir string, files []string) error {
+	return UpdateProjectState(projectDir, func(state *ProjectState) error {
+		state.Pinned = fileList(files)
+		return nil
+	})
+}
+
+// GetProjectHidden returns the files hidden in the TUI. saved is false when
+// the project never changed them, so the config's defaults apply.
+func GetProjectHidden(projectDir string) (files []string, saved bool, err error) {
+	state, err := LoadProjectState(projectDir)
+	if err != nil || state.Hidden == nil {
+		return nil, false, err
+	}
+	return *state.Hidden, true, nil
+}
+
+func SaveProjectHidden(projectDir string, files []string) error {
+	return UpdateProjectState(projectDir, func(state *ProjectState) error {
+		state.Hidden = fileList(files)
+		return nil
+	})
+}
+
+// fileList returns a pointer to files that is saved even when empty.
+func fileList(files []string) *[]string {
+	if files == nil {
+		files = []string{}
+	}
+	return &files
+}
+
+func GetProjectHistory(projectDir string) ([]RunRecord, error) {
+	state, err := LoadProjectState(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	return state.History, nil
+}
+
+// GetProjectFailureMessages returns the failure output recorded for each
+// file that failed in the most recent run.
+func GetProjectFailureMessages(projectDir string) (map[string]string, error) {
+	state, err := LoadProjectState(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	return state.FailureMessages, nil
+}
+
+// SaveProjectRun appends record to the project's run history and replaces
+// the saved failures, and their messages, with the files that failed in it.
+func SaveProjectRun(projectDir string, record RunRecord, messages map[string]string) error {
+	return UpdateProjectState(projectDir, func(state *ProjectState) error {
+		failed := record.Failed
+		if failed == nil {
+			failed = []string{}
+		}
+		state.Failures = failed
+		state.FailureMessages = nil
+		for _, file := range failed {
+			if msg, ok := messages[file]; ok {
+				if state.FailureMessages == nil {
+					state.FailureMessages = map[string]string{}
+				}
+				state.FailureMessages[file] = msg
+			}
+		}
+		state.History = append(state.History, record)
+		if excess := len(state.History) - maxRunHistory; excess > 0 {
+			state.History = append([]RunRecord(nil), state.History[excess:]...)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,21 @@
+//go:build !unix
+
+package doctor
+
+import (
+	"errors"
+	"os"
+)
+
+// canWrite reports whether dir looks writable from its permission bits; the
+// platforms this builds on have no access(2).
+func canWrite(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if info.Mode().Perm()&0200 == 0 {
+		return errors.New("permission denied")
+	}
+	return nil
+}
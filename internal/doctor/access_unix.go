@@ -0,0 +1,11 @@
+//go:build unix
+
+package doctor
+
+import "golang.org/x/sys/unix"
+
+// canWrite reports whether the current user may create files in dir,
+// without creating anything.
+func canWrite(dir string) error {
+	return unix.Access(dir, unix.W_OK)
+}
@@ -0,0 +1,348 @@
+package doctor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/samrobinsonsauce/eztest/internal/config"
+	"github.com/samrobinsonsauce/eztest/internal/testfile"
+)
+
+type Status string
+
+const (
+	Pass Status = "pass"
+	Warn Status = "warn"
+	Fail Status = "fail"
+)
+
+// Result is the outcome of a single health check.
+type Result struct {
+	Name    string `json:"name"`
+	Status  Status `json:"status"`
+	Message string `json:"message"`
+	Hint    string `json:"hint,omitempty"`
+}
+
+// Env holds everything the checks need so they can be exercised in tests
+// without touching the real system.
+type Env struct {
+	ProjectDir  string
+	Settings    config.AppSettings
+	CheckRules  config.CheckRules
+	LookPath    func(string) (string, error)
+	CommandText func(name string, args ...string) (string, error)
+}
+
+// DefaultEnv returns an Env backed by the real PATH and processes.
+func DefaultEnv(projectDir string, settings config.AppSettings, rules config.CheckRules) Env {
+	return Env{
+		ProjectDir: projectDir,
+		Settings:   settings,
+		CheckRules: rules,
+		LookPath:   exec.LookPath,
+		CommandText: func(name string, args ...string) (string, error) {
+			out, err := exec.Command(name, args...).CombinedOutput()
+			return string(out), err
+		},
+	}
+}
+
+// minimum Elixir versions for the mix test features ezt relies on.
+var featureVersions = []struct {
+	feature string
+	major   int
+	minor   int
+}{
+	{feature: "file:line filters", major: 1, minor: 3},
+	{feature: "--partitions", major: 1, minor: 10},
+}
+
+var elixirVersionPattern = regexp.MustCompile(`Elixir (\d+)\.(\d+)(?:\.(\d+))?`)
+
+// Run executes every check in order.
+func Run(env Env) []Result {
+	return []Result{
+		checkRunner(env),
+		checkElixir(env),
+		checkProjectRoot(env),
+		checkTestFiles(env),
+		checkConfig(env),
+		checkState(env),
+		checkLegacyDir(),
+	}
+}
+
+// Failed reports whether any result failed.
+func Failed(results []Result) bool {
+	for _, r := range results {
+		if r.Status == Fail {
+			return true
+		}
+	}
+	return false
+}
+
+func checkRunner(env Env) Result {
+	command := env.Settings.Run.Command
+	if len(command) == 0 {
+		command = config.DefaultAppSettings().Run.Command
+	}
+	name := "test command on PATH"
+
+	path, err := env.LookPath(command[0])
+	if err != nil {
+		return Result{
+			Name:    name,
+			Status:  Fail,
+			Message: fmt.Sprintf("%q not found on PATH", command[0]),
+			Hint:    "Install Elixir (https://elixir-lang.org/install.html) or set run.command in your config",
+		}
+	}
+	return Result{Name: name, Status: Pass, Message: path}
+}
+
+func checkElixir(env Env) Result {
+	name := "elixir version"
+
+	out, err := env.CommandText("elixir", "--version")
+	if err != nil {
+		return Result{
+			Name:    name,
+			Status:  Warn,
+			Message: "could not run `elixir --version`",
+			Hint:    "Make sure elixir is on PATH so ezt can check feature support",
+		}
+	}
+
+	m := elixirVersionPattern.FindStringSubmatch(out)
+	if m == nil {
+		return Result{Name: name, Status: Warn, Message: "could not parse `elixir --version` output"}
+	}
+	major, _ := strconv.Atoi(m[1])
+	minor, _ := strconv.Atoi(m[2])
+	version := strings.TrimPrefix(m[0], "Elixir ")
+
+	var missing []string
+	for _, fv := range featureVersions {
+		if major < fv.major || (major == fv.major && minor < fv.minor) {
+			missing = append(missing, fmt.Sprintf("%s (needs %d.%d+)", fv.feature, fv.major, fv.minor))
+		}
+	}
+	if len(missing) > 0 {
+		return Result{
+			Name:    name,
+			Status:  Warn,
+			Message: fmt.Sprintf("Elixir %s lacks %s", version, strings.Join(missing, ", ")),
+			Hint:    "Upgrade Elixir to use every ezt feature",
+		}
+	}
+
+	features := make([]string, len(featureVersions))
+	for i, fv := range featureVersions {
+		features[i] = fv.feature
+	}
+	return Result{Name: name, Status: Pass, Message: fmt.Sprintf("Elixir %s supports %s", version, strings.Join(features, " and "))}
+}
+
+func checkProjectRoot(env Env) Result {
+	name := "project root"
+
+	if _, err := os.Stat(filepath.Join(env.ProjectDir, "mix.exs")); err != nil {
+		if parent := findMixProject(filepath.Dir(env.ProjectDir)); parent != "" {
+			return Result{
+				Name:    name,
+				Status:  Fail,
+				Message: fmt.Sprintf("no mix.exs in %s", env.ProjectDir),
+				Hint:    fmt.Sprintf("Run ezt from the project root: cd %s", parent),
+			}
+		}
+		return Result{
+			Name:    name,
+			Status:  Fail,
+			Message: fmt.Sprintf("no mix.exs in %s or any parent directory", env.ProjectDir),
+			Hint:    "Run ezt from inside an Elixir/Phoenix project",
+		}
+	}
+
+	// Inside an umbrella app: apps/<name>/mix.exs with an umbrella root above.
+	if filepath.Base(filepath.Dir(env.ProjectDir)) == "apps" {
+		umbrella := filepath.Dir(filepath.Dir(env.ProjectDir))
+		if _, err := os.Stat(filepath.Join(umbrella, "mix.exs")); err == nil {
+			return Result{
+				Name:    name,
+				Status:  Warn,
+				Message: fmt.Sprintf("%s is an umbrella child app", env.ProjectDir),
+				Hint:    fmt.Sprintf("Run ezt from %s to see tests for every app", umbrella),
+			}
+		}
+	}
+
+	return Result{Name: name, Status: Pass, Message: env.ProjectDir}
+}
+
+func findMixProject(dir string) string {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "mix.exs")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+func checkTestFiles(env Env) Result {
+	name := "test files"
+
+	files, err := testfile.Discover(env.ProjectDir, testfile.Options{
+		Paths:   env.Settings.TestPaths,
+		Exclude: env.Settings.Exclude,
+	})
+	if err != nil {
+		return Result{
+			Name:    name,
+			Status:  Fail,
+			Message: firstLine(err.Error()),
+			Hint:    fmt.Sprintf("Check test_paths (%s) and exclude in your config", strings.Join(env.Settings.TestPaths, ", ")),
+		}
+	}
+	return Result{Name: name, Status: Pass, Message: fmt.Sprintf("%d test file(s) found", len(files))}
+}
+
+func checkConfig(env Env) Result {
+	name := "config files"
+
+	files := config.ConfigFiles(env.ProjectDir)
+	if len(files) == 0 {
+		return Result{Name: name, Status: Pass, Message: "no config files; using defaults"}
+	}
+
+	errors, warnings := 0, 0
+	for _, issue := range config.CheckFiles(files, env.CheckRules) {
+		if issue.Severity == config.SeverityError {
+			errors++
+		} else {
+			warnings++
+		}
+	}
+
+	message := fmt.Sprintf("%s readable", strings.Join(files, ", "))
+	switch {
+	case errors > 0:
+		return Result{
+			Name:    name,
+			Status:  Fail,
+			Message: fmt.Sprintf("%d error(s), %d warning(s) in %s", errors, warnings, strings.Join(files, ", ")),
+			Hint:    "Run `ezt config check` for details",
+		}
+	case warnings > 0:
+		return Result{
+			Name:    name,
+			Status:  Warn,
+			Message: fmt.Sprintf("%d warning(s) in %s", warnings, strings.Join(files, ", ")),
+			Hint:    "Run `ezt config check` for details",
+		}
+	}
+	return Result{Name: name, Status: Pass, Message: message}
+}
+
+func checkState(env Env) Result {
+	name := "state"
+
+	path, err := config.GetProjectStatePath(env.ProjectDir)
+	if err != nil {
+		return Result{Name: name, Status: Fail, Message: err.Error(), Hint: "Set HOME or XDG_STATE_HOME"}
+	}
+	dir := filepath.Dir(path)
+
+	// Read the file directly: loading it would migrate legacy state and
+	// move a corrupt file aside, and doctor must not change anything.
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return Result{
+			Name:    name,
+			Status:  Fail,
+			Message: fmt.Sprintf("cannot read %s: %v", path, err),
+			Hint:    "Fix the file permissions or run `ezt state delete` to start over",
+		}
+	}
+	exists := err == nil
+	if exists {
+		var state config.ProjectState
+		if err := json.Unmarshal(data, &state); err != nil {
+			return Result{
+				Name:    name,
+				Status:  Fail,
+				Message: fmt.Sprintf("%s is corrupt: %v", path, err),
+				Hint:    "Run `ezt state delete` to start over",
+			}
+		}
+	}
+
+	// The state directory is created on first use, so check the nearest
+	// directory that already exists.
+	existing := dir
+	for {
+		if _, err := os.Stat(existing); err == nil || !os.IsNotExist(err) {
+			break
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			break
+		}
+		existing = parent
+	}
+	if err := canWrite(existing); err != nil {
+		return Result{Name: name, Status: Fail, Message: fmt.Sprintf("%s is not writable: %v", existing, err), Hint: "Check permissions on your state directory"}
+	}
+
+	if !exists {
+		return Result{Name: name, Status: Pass, Message: fmt.Sprintf("no state yet; %s can be created", path)}
+	}
+	return Result{Name: name, Status: Pass, Message: fmt.Sprintf("%s readable and writable", path)}
+}
+
+func checkLegacyDir() Result {
+	name := "legacy config dir"
+
+	legacyDir, err := config.GetLegacyConfigDir()
+	if err != nil {
+		return Result{Name: name, Status: Pass, Message: "none"}
+	}
+	if _, err := os.Stat(legacyDir); err != nil {
+		return Result{Name: name, Status: Pass, Message: "none"}
+	}
+
+	hint := fmt.Sprintf("Remove %s once you've moved any config.json", legacyDir)
+	if _, err := os.Stat(filepath.Join(legacyDir, "state.json")); err == nil {
+		// Doctor doesn't load state, so the migration may not have run yet.
+		hint = fmt.Sprintf("Run `ezt` once to migrate %s, then remove %s", filepath.Join(legacyDir, "state.json"), legacyDir)
+	} else if _, err := os.Stat(filepath.Join(legacyDir, "state.json.migrated")); err == nil {
+		hint = fmt.Sprintf("Its state has been migrated; remove %s once you've moved any config.json", legacyDir)
+	}
+	if appPath, err := config.GetAppConfigPath(); err == nil {
+		if _, err := os.Stat(filepath.Join(legacyDir, "config.json")); err == nil {
+			if _, err := os.Stat(appPath); os.IsNotExist(err) {
+				hint = fmt.Sprintf("Move %s to %s", filepath.Join(legacyDir, "config.json"), appPath)
+			}
+		}
+	}
+
+	return Result{Name: name, Status: Warn, Message: fmt.Sprintf("legacy directory %s still exists", legacyDir), Hint: hint}
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
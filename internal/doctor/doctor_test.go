@@ -0,0 +1,157 @@
+package doctor
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/samrobinsonsauce/eztest/internal/config"
+)
+
+func testEnv(t *testing.T, elixirOutput string) Env {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("XDG_STATE_HOME", "")
+
+	project := t.TempDir()
+	for _, path := range []string{"mix.exs", "test/user_test.exs"} {
+		full := filepath.Join(project, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(full, nil, 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+	}
+
+	return Env{
+		ProjectDir: project,
+		Settings:   config.DefaultAppSettings(),
+		LookPath: func(name string) (string, error) {
+			return "/usr/bin/" + name, nil
+		},
+		CommandText: func(name string, args ...string) (string, error) {
+			return elixirOutput, nil
+		},
+	}
+}
+
+func resultByName(results []Result, name string) Result {
+	for _, r := range results {
+		if r.Name == name {
+			return r
+		}
+	}
+	return Result{}
+}
+
+func TestRunPassesForHealthyProject(t *testing.T) {
+	env := testEnv(t, "Erlang/OTP 26\n\nElixir 1.15.7 (compiled with Erlang/OTP 26)\n")
+
+	results := Run(env)
+	for _, r := range results {
+		if r.Status != Pass {
+			t.Errorf("expected %s to pass, got %s: %s", r.Name, r.Status, r.Message)
+		}
+	}
+	if Failed(results) {
+		t.Fatalf("expected no failures")
+	}
+}
+
+func TestRunReportsMissingMixAndOldElixir(t *testing.T) {
+	env := testEnv(t, "Elixir 1.7.4 (compiled with Erlang/OTP 21)")
+	env.LookPath = func(name string) (string, error) {
+		return "", errors.New("not found")
+	}
+
+	results := Run(env)
+	if r := resultByName(results, "test command on PATH"); r.Status != Fail || r.Hint == "" {
+		t.Fatalf("expected missing mix to fail with a hint, got %+v", r)
+	}
+	if r := resultByName(results, "elixir version"); r.Status != Warn {
+		t.Fatalf("expected old Elixir to warn, got %+v", r)
+	}
+	if !Failed(results) {
+		t.Fatalf("expected Failed to report the missing runner")
+	}
+}
+
+func TestCheckProjectRootSuggestsParent(t *testing.T) {
+	env := testEnv(t, "")
+	env.ProjectDir = filepath.Join(env.ProjectDir, "test")
+
+	r := checkProjectRoot(env)
+	if r.Status != Fail || r.Hint == "" {
+		t.Fatalf("expected project root check to fail with a hint, got %+v", r)
+	}
+}
+
+func TestCheckLegacyDirWarnsWhenPresent(t *testing.T) {
+	_ = testEnv(t, "")
+	legacy, err := config.GetLegacyConfigDir()
+	if err != nil {
+		t.Fatalf("GetLegacyConfigDir returned error: %v", err)
+	}
+	if err := os.MkdirAll(legacy, 0755); err != nil {
+		t.Fatalf("failed to create legacy dir: %v", err)
+	}
+
+	if r := checkLegacyDir(); r.Status != Warn || strings.Contains(r.Hint, "migrated") {
+		t.Fatalf("expected legacy dir to warn without claiming a migration, got %+v", r)
+	}
+
+	if err := os.WriteFile(filepath.Join(legacy, "state.json"), []byte("{}"), 0644); err != nil {
+		t.Fatalf("failed to write legacy state: %v", err)
+	}
+	if r := checkLegacyDir(); !strings.Contains(r.Hint, "to migrate") {
+		t.Fatalf("expected the hint to ask for a migration first, got %+v", r)
+	}
+}
+
+func TestCheckStateCreatesNothing(t *testing.T) {
+	env := testEnv(t, "")
+	path, err := config.GetProjectStatePath(env.ProjectDir)
+	if err != nil {
+		t.Fatalf("GetProjectStatePath returned error: %v", err)
+	}
+
+	if r := checkState(env); r.Status != Pass {
+		t.Fatalf("expected a missing state file to pass, got %+v", r)
+	}
+	if _, err := os.Stat(filepath.Dir(path)); !os.IsNotExist(err) {
+		t.Fatalf("expected doctor not to create the state directory, got %v", err)
+	}
+}
+
+func TestCheckStateReportsCorruptFileWithoutMovingIt(t *testing.T) {
+	env := testEnv(t, "")
+	path, err := config.GetProjectStatePath(env.ProjectDir)
+	if err != nil {
+		t.Fatalf("GetProjectStatePath returned error: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create state dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("{not json"), 0644); err != nil {
+		t.Fatalf("failed to write state: %v", err)
+	}
+
+	if r := checkState(env); r.Status != Fail || !strings.Contains(r.Message, "corrupt") {
+		t.Fatalf("expected a corrupt state file to fail, got %+v", r)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "{not json" {
+		t.Fatalf("expected doctor to leave the state file alone, got %q (%v)", data, err)
+	}
+}
+
+func TestPartitionsNeedElixir110(t *testing.T) {
+	env := testEnv(t, "Elixir 1.9.4 (compiled with Erlang/OTP 22)")
+	if r := checkElixir(env); r.Status != Warn || !strings.Contains(r.Message, "--partitions") {
+		t.Fatalf("expected Elixir 1.9 to lack --partitions, got %+v", r)
+	}
+}
@@ -0,0 +1,269 @@
+package search
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// State tokens restrict results by a file's state. Hidden files only match
+// queries containing @hidden.
+const (
+	SelectedToken   = "@selected"
+	UnselectedToken = "@unselected"
+	FailedToken     = "@failed"
+	ChangedToken    = "@changed"
+	PinnedToken     = "@pinned"
+	HiddenToken     = "@hidden"
+)
+
+var stateTokens = []string{SelectedToken, UnselectedToken, FailedToken, ChangedToken, PinnedToken, HiddenToken}
+
+type termKind int
+
+const (
+	termFuzzy termKind = iota
+	termExact
+	termRegex
+	termState
+	termDir
+	termApp
+)
+
+type term struct {
+	kind   termKind
+	value  string
+	negate bool
+	re     *regexp.Regexp
+}
+
+// ContentPrefix switches a query to content mode, matching module names
+// and describe/test strings instead of paths.
+const ContentPrefix = "#"
+
+// Query is a parsed search query: alternatives separated by "|", each a list
+// of terms that must all match. In content mode the text after the prefix
+// is matched against each file's content entries instead.
+type Query struct {
+	alternatives [][]term
+	content      string
+	contentMode  bool
+}
+
+// ParseError describes an invalid query. Pos is the byte offset of the
+// offending term in the query.
+type ParseError struct {
+	Pos int
+	Msg string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("col %d: %s", e.Pos+1, e.Msg)
+}
+
+// token is a raw whitespace-separated word and where it starts.
+type token struct {
+	text string
+	pos  int
+}
+
+// Parse parses the query grammar used by the search box:
+//
+//	term          fuzzy match against the path
+//	'term         literal substring
+//	/regex/       regular expression (may contain spaces)
+//	dir:path      files under a directory
+//	app:name      files in an umbrella app (apps/name/)
+//	@selected @unselected @failed @changed @pinned @hidden
+//	!term         negates any of the above
+//	a b | c       matches (a and b) or c
+//
+// Fuzzy and literal terms are case-insensitive unless they contain an
+// upper-case letter (smart case).
+//
+// A query starting with # is a content search; the rest is matched as a
+// single fuzzy pattern against module names and describe/test strings.
+func Parse(query string) (Query, error) {
+	if trimmed := strings.TrimSpace(query); strings.HasPrefix(trimmed, ContentPrefix) {
+		return Query{contentMode: true, content: strings.TrimSpace(trimmed[len(ContentPrefix):])}, nil
+	}
+
+	tokens, err := tokenize(query)
+	if err != nil {
+		return Query{}, err
+	}
+
+	var q Query
+	var current []term
+	lastPipe := -1
+	for _, tok := range tokens {
+		if tok.text == "|" {
+			if len(current) == 0 {
+				return Query{}, &ParseError{Pos: tok.pos, Msg: "nothing before |"}
+			}
+			q.alternatives = append(q.alternatives, current)
+			current = nil
+			lastPipe = tok.pos
+			continue
+		}
+
+		t, err := parseTerm(tok)
+		if err != nil {
+			return Query{}, err
+		}
+		current = append(current, t)
+	}
+
+	if len(current) == 0 && lastPipe >= 0 {
+		return Query{}, &ParseError{Pos: lastPipe, Msg: "nothing after |"}
+	}
+	if len(current) > 0 {
+		q.alternatives = append(q.alternatives, current)
+	}
+	return q, nil
+}
+
+// Empty reports whether the query matches everything.
+func (q Query) Empty() bool {
+	if q.contentMode {
+		return q.content == ""
+	}
+	return len(q.alternatives) == 0
+}
+
+// showsHidden reports whether the query asks for hidden files with a
+// @hidden term that isn't negated.
+func (q Query) showsHidden() bool {
+	for _, alt := range q.alternatives {
+		for _, t := range alt {
+			if t.kind == termState && t.value == HiddenToken && !t.negate {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ContentMode reports whether the query searches file contents.
+func (q Query) ContentMode() bool {
+	return q.contentMode
+}
+
+// tokenize splits on whitespace, keeping /regex/ terms (which may contain
+// spaces) together.
+func tokenize(query string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(query) {
+		if isSpace(query[i]) {
+			i++
+			continue
+		}
+
+		start := i
+		body := i
+		if query[body] == '!' {
+			body++
+		}
+		if body < len(query) && query[body] == '/' {
+			end := closingSlash(query, body+1)
+			if end < 0 {
+				return nil, &ParseError{Pos: start, Msg: "unterminated /regex/"}
+			}
+			i = end + 1
+			if i < len(query) && !isSpace(query[i]) {
+				return nil, &ParseError{Pos: i, Msg: "expected a space after /regex/"}
+			}
+			tokens = append(tokens, token{text: query[start:i], pos: start})
+			continue
+		}
+
+		for i < len(query) && !isSpace(query[i]) {
+			i++
+		}
+		tokens = append(tokens, token{text: query[start:i], pos: start})
+	}
+	return tokens, nil
+}
+
+// closingSlash returns the index of the next unescaped "/" at or after i.
+func closingSlash(s string, i int) int {
+	for ; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '/':
+			return i
+		}
+	}
+	return -1
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t'
+}
+
+func parseTerm(tok token) (term, error) {
+	text := tok.text
+	var t term
+	if strings.HasPrefix(text, "!") {
+		t.negate = true
+		text = text[1:]
+		if text == "" {
+			return term{}, &ParseError{Pos: tok.pos, Msg: "! must be followed by a term"}
+		}
+	}
+
+	lower := strings.ToLower(text)
+	switch {
+	case strings.HasPrefix(text, "/"):
+		pattern := text[1 : len(text)-1]
+		if pattern == "" {
+			return term{}, &ParseError{Pos: tok.pos, Msg: "empty /regex/"}
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return term{}, &ParseError{Pos: tok.pos, Msg: fmt.Sprintf("invalid regex: %v", regexErrorMessage(err))}
+		}
+		t.kind, t.value, t.re = termRegex, pattern, re
+	case strings.HasPrefix(text, "'"):
+		if len(text) == 1 {
+			return term{}, &ParseError{Pos: tok.pos, Msg: "' must be followed by text"}
+		}
+		t.kind, t.value = termExact, text[1:]
+	case strings.HasPrefix(text, "@"):
+		if !contains(stateTokens, lower) {
+			return term{}, &ParseError{Pos: tok.pos, Msg: fmt.Sprintf("unknown filter %s (valid: %s)", text, strings.Join(stateTokens, ", "))}
+		}
+		t.kind, t.value = termState, lower
+	case strings.HasPrefix(lower, "dir:"):
+		value := strings.Trim(strings.TrimPrefix(text[len("dir:"):], "./"), "/")
+		if value == "" {
+			return term{}, &ParseError{Pos: tok.pos, Msg: "dir: needs a directory"}
+		}
+		t.kind, t.value = termDir, value
+	case strings.HasPrefix(lower, "app:"):
+		value := strings.Trim(text[len("app:"):], "/")
+		if value == "" {
+			return term{}, &ParseError{Pos: tok.pos, Msg: "app: needs an app name"}
+		}
+		t.kind, t.value = termApp, value
+	default:
+		t.kind, t.value = termFuzzy, text
+	}
+	return t, nil
+}
+
+// regexErrorMessage drops the "error parsing regexp: " prefix.
+func regexErrorMessage(err error) string {
+	return strings.TrimPrefix(err.Error(), "error parsing regexp: ")
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,327 @@
+// Package search implements the query matching shared by the TUI search box
+// and the non-interactive --filter mode.
+package search
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/sahilm/fuzzy"
+	"github.com/samrobinsonsauce/eztest/internal/testfile"
+)
+
+// Candidate is a test file that a query can match.
+type Candidate struct {
+	Path     string
+	Failed   bool
+	Selected bool
+	// Changed marks files touched by uncommitted changes.
+	Changed bool
+	// Pinned files are kept at the top of the TUI list. Hidden files only
+	// match queries containing @hidden.
+	Pinned bool
+	Hidden bool
+	// Content holds the searchable module, describe and test strings.
+	// Content mode never matches a candidate without content.
+	Content []ContentEntry
+}
+
+// ContentEntry is a searchable string from a file and its line number.
+type ContentEntry struct {
+	Text string
+	Line int
+}
+
+// Match is a candidate that satisfied the query. Index refers to the slice
+// passed to Apply and Positions holds the byte offsets of the matched
+// characters in its path, in ascending order.
+type Match struct {
+	Index     int
+	Score     int
+	Positions []int
+	// In content mode, Detail is the best matching content entry, Line its
+	// line number and DetailPositions the matched offsets within it.
+	Detail          string
+	Line            int
+	DetailPositions []int
+}
+
+// Scores for the different kinds of term. Fuzzy terms are scored by the
+// matcher; basenameBonus favours terms that match within the file name over
+// the same characters spread through the directories.
+const (
+	basenameBonus = 30
+	exactScore    = 60
+	regexScore    = 10
+)
+
+// Apply returns the candidates matching q, best match first. An empty query
+// matches everything in the original order. A candidate matching several
+// alternatives keeps the best-scoring one. Hidden candidates are skipped
+// unless q asks for them with @hidden.
+func (q Query) Apply(candidates []Candidate) []Match {
+	if q.contentMode && q.content != "" {
+		return q.applyContent(candidates)
+	}
+
+	showHidden := q.showsHidden()
+	matches := make([]Match, 0, len(candidates))
+	for i, c := range candidates {
+		if c.Hidden && !showHidden {
+			continue
+		}
+		if q.Empty() {
+			matches = append(matches, Match{Index: i})
+			continue
+		}
+
+		var best Match
+		found := false
+		for _, alt := range q.alternatives {
+			m, ok := matchAlternative(alt, c)
+			if ok && (!found || m.Score > best.Score) {
+				best, found = m, true
+			}
+		}
+		if found {
+			best.Index = i
+			matches = append(matches, best)
+		}
+	}
+
+	if q.ranked() {
+		rank(matches, candidates)
+	}
+	return matches
+}
+
+// applyContent matches the content pattern against every entry of each
+// candidate and keeps the best entry per file.
+func (q Query) applyContent(candidates []Candidate) []Match {
+	caseSensitive := hasUpper(q.content)
+	texts := make([]string, 0, 16)
+
+	var matches []Match
+	for i, c := range candidates {
+		if c.Hidden {
+			continue
+		}
+		texts = texts[:0]
+		for _, entry := range c.Content {
+			texts = append(texts, entry.Text)
+		}
+
+		best := Match{Index: i}
+		found := false
+		for _, fm := range fuzzy.FindNoSort(q.content, texts) {
+			if caseSensitive && !sameCase(q.content, fm.Str, fm.MatchedIndexes) {
+				continue
+			}
+			if found && fm.Score <= best.Score {
+				continue
+			}
+			entry := c.Content[fm.Index]
+			best.Score, best.Detail, best.Line = fm.Score, entry.Text, entry.Line
+			best.DetailPositions = append([]int(nil), fm.MatchedIndexes...)
+			found = true
+		}
+		if found {
+			matches = append(matches, best)
+		}
+	}
+
+	rank(matches, candidates)
+	return matches
+}
+
+// rank orders matches by score, then shorter paths first.
+func rank(matches []Match, candidates []Candidate) {
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return len(candidates[matches[i].Index].Path) < len(candidates[matches[j].Index].Path)
+	})
+}
+
+// ranked reports whether any term contributes a score; queries made only of
+// filters keep the original order.
+func (q Query) ranked() bool {
+	for _, alt := range q.alternatives {
+		for _, t := range alt {
+			if !t.negate && (t.kind == termFuzzy || t.kind == termExact || t.kind == termRegex) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchAlternative(terms []term, c Candidate) (Match, bool) {
+	var m Match
+	for _, t := range terms {
+		score, positions, ok := matchTerm(t, c)
+		if t.negate {
+			if ok {
+				return Match{}, false
+			}
+			continue
+		}
+		if !ok {
+			return Match{}, false
+		}
+		m.Score += score
+		m.Positions = mergePositions(m.Positions, positions)
+	}
+	return m, true
+}
+
+func matchTerm(t term, c Candidate) (int, []int, bool) {
+	path := c.Path
+	switch t.kind {
+	case termFuzzy:
+		return fuzzyMatch(t.value, path)
+	case termExact:
+		return exactMatch(t.value, path)
+	case termRegex:
+		loc := t.re.FindStringIndex(path)
+		if loc == nil {
+			return 0, nil, false
+		}
+		return regexScore, span(loc[0], loc[1]), true
+	case termState:
+		switch t.value {
+		case SelectedToken:
+			return 0, nil, c.Selected
+		case UnselectedToken:
+			return 0, nil, !c.Selected
+		case FailedToken:
+			return 0, nil, c.Failed
+		case ChangedToken:
+			return 0, nil, c.Changed
+		case PinnedToken:
+			return 0, nil, c.Pinned
+		case HiddenToken:
+			return 0, nil, c.Hidden
+		}
+	case termDir:
+		return 0, nil, strings.HasPrefix(path, t.value+"/") || strings.Contains(path, "/"+t.value+"/")
+	case termApp:
+		return 0, nil, strings.HasPrefix(path, "apps/"+t.value+"/")
+	}
+	return 0, nil, false
+}
+
+// fuzzyMatch scores token against the whole path and against the file name
+// alone, keeping whichever is better.
+func fuzzyMatch(token, path string) (int, []int, bool) {
+	caseSensitive := hasUpper(token)
+	offset := baseOffset(path)
+
+	score, found := 0, false
+	var positions []int
+	for _, fm := range fuzzy.FindNoSort(token, []string{path, path[offset:]}) {
+		if caseSensitive && !sameCase(token, fm.Str, fm.MatchedIndexes) {
+			continue
+		}
+		s := fm.Score
+		shift := 0
+		if fm.Index == 1 {
+			s += basenameBonus
+			shift = offset
+		}
+		if found && s <= score {
+			continue
+		}
+		score, found = s, true
+		positions = make([]int, len(fm.MatchedIndexes))
+		for i, p := range fm.MatchedIndexes {
+			positions[i] = p + shift
+		}
+	}
+	return score, positions, found
+}
+
+// exactMatch finds a literal substring, preferring the last occurrence so
+// a hit in the file name wins over one in a directory.
+func exactMatch(value, path string) (int, []int, bool) {
+	haystack, needle := path, value
+	if !hasUpper(value) {
+		haystack, needle = strings.ToLower(path), strings.ToLower(value)
+	}
+	i := strings.LastIndex(haystack, needle)
+	if i < 0 {
+		return 0, nil, false
+	}
+	score := exactScore + len(needle)
+	if i >= baseOffset(path) {
+		score += basenameBonus
+	}
+	return score, span(i, i+len(needle)), true
+}
+
+func span(start, end int) []int {
+	out := make([]int, 0, end-start)
+	for i := start; i < end; i++ {
+		out = append(out, i)
+	}
+	return out
+}
+
+func baseOffset(path string) int {
+	return strings.LastIndexByte(path, '/') + 1
+}
+
+func hasUpper(s string) bool {
+	for _, r := range s {
+		if unicode.IsUpper(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameCase reports whether the matched characters agree with token exactly;
+// the underlying matcher always folds case.
+func sameCase(token, text string, positions []int) bool {
+	i := 0
+	for _, want := range token {
+		if i >= len(positions) {
+			return false
+		}
+		got, _ := utf8.DecodeRuneInString(text[positions[i]:])
+		if got != want {
+			return false
+		}
+		i++
+	}
+	return true
+}
+
+func mergePositions(a, b []int) []int {
+	if len(a) == 0 {
+		return b
+	}
+	merged := append(append([]int(nil), a...), b...)
+	sort.Ints(merged)
+	out := merged[:0]
+	for i, p := range merged {
+		if i > 0 && p == merged[i-1] {
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// OutlineContent turns a file outline into content entries: module names,
+// describe names and tests prefixed with their describe block.
+func OutlineContent(outline []testfile.OutlineEntry) []ContentEntry {
+	entries := make([]ContentEntry, len(outline))
+	for i, e := range outline {
+		entries[i] = ContentEntry{Text: e.FullName(), Line: e.Line}
+	}
+	return entries
+}
@@ -0,0 +1,251 @@
+package search
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func candidates(paths ...string) []Candidate {
+	out := make([]Candidate, len(paths))
+	for i, p := range paths {
+		out[i] = Candidate{Path: p}
+	}
+	return out
+}
+
+func mustMatches(t *testing.T, query string, in []Candidate) []Match {
+	t.Helper()
+	q, err := Parse(query)
+	if err != nil {
+		t.Fatalf("Parse(%q) returned error: %v", query, err)
+	}
+	return q.Apply(in)
+}
+
+func mustPaths(t *testing.T, query string, in []Candidate) []string {
+	t.Helper()
+	matches := mustMatches(t, query, in)
+	got := make([]string, len(matches))
+	for i, m := range matches {
+		got[i] = in[m.Index].Path
+	}
+	return got
+}
+
+func TestEmptyQueryKeepsOrder(t *testing.T) {
+	in := candidates("test/b_test.exs", "test/a_test.exs")
+	got := mustPaths(t, "  ", in)
+	want := []string{"test/b_test.exs", "test/a_test.exs"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v want %v", got, want)
+	}
+}
+
+func TestEveryTermMustMatchAndContiguousRanksFirst(t *testing.T) {
+	in := candidates(
+		"test/my_app/accounts/user_settings_test.exs",
+		"test/my_app_web/controllers/user_controller_test.exs",
+		"test/my_app_web/controllers/page_controller_test.exs",
+	)
+
+	got := mustPaths(t, "user controller", in)
+	want := []string{"test/my_app_web/controllers/user_controller_test.exs"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v want %v", got, want)
+	}
+
+	got = mustPaths(t, "usr", in)
+	if len(got) != 2 {
+		t.Fatalf("expected fuzzy match on both user files, got %v", got)
+	}
+}
+
+func TestFailedToken(t *testing.T) {
+	in := []Candidate{
+		{Path: "test/api/a_test.exs", Failed: true},
+		{Path: "test/web/b_test.exs", Failed: true},
+		{Path: "test/api/c_test.exs"},
+	}
+
+	got := mustPaths(t, "@FAILED api", in)
+	want := []string{"test/api/a_test.exs"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v want %v", got, want)
+	}
+}
+
+func TestHiddenFilesOnlyMatchHiddenToken(t *testing.T) {
+	in := []Candidate{
+		{Path: "test/features/checkout_test.exs", Hidden: true},
+		{Path: "test/my_app/checkout_test.exs", Pinned: true},
+		{Path: "test/my_app/cart_test.exs"},
+	}
+
+	cases := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"test/my_app/checkout_test.exs", "test/my_app/cart_test.exs"}},
+		{"checkout", []string{"test/my_app/checkout_test.exs"}},
+		{"@hidden", []string{"test/features/checkout_test.exs"}},
+		{"@hidden | cart", []string{"test/my_app/cart_test.exs", "test/features/checkout_test.exs"}},
+		{"!@hidden", []string{"test/my_app/checkout_test.exs", "test/my_app/cart_test.exs"}},
+		{"@pinned", []string{"test/my_app/checkout_test.exs"}},
+	}
+	for _, tc := range cases {
+		if got := mustPaths(t, tc.query, in); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("query %q: got %v want %v", tc.query, got, tc.want)
+		}
+	}
+}
+
+func TestBasenameMatchesBeatDirectoryMatches(t *testing.T) {
+	in := candidates(
+		"test/my_app/user/settings_test.exs",
+		"test/my_app/accounts/user_test.exs",
+	)
+
+	got := mustPaths(t, "user", in)
+	if got[0] != "test/my_app/accounts/user_test.exs" {
+		t.Fatalf("expected basename match first, got %v", got)
+	}
+}
+
+func TestSmartCase(t *testing.T) {
+	in := candidates("test/api/Schema_test.exs", "test/api/schema_test.exs")
+
+	if got := mustPaths(t, "schema", in); len(got) != 2 {
+		t.Fatalf("lower-case query should ignore case, got %v", got)
+	}
+	got := mustPaths(t, "Schema", in)
+	want := []string{"test/api/Schema_test.exs"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v want %v", got, want)
+	}
+}
+
+func TestPositionsCoverEveryTerm(t *testing.T) {
+	in := candidates("test/web/user_controller_test.exs")
+
+	matches := mustMatches(t, "user ctrl", in)
+	if len(matches) != 1 {
+		t.Fatalf("expected one match, got %v", matches)
+	}
+	path := in[0].Path
+	var matched strings.Builder
+	for _, p := range matches[0].Positions {
+		matched.WriteByte(path[p])
+	}
+	if got := matched.String(); got != "userctrl" {
+		t.Fatalf("expected positions to spell the query terms, got %q (%v)", got, matches[0].Positions)
+	}
+}
+
+func TestQueryGrammar(t *testing.T) {
+	in := []Candidate{
+		{Path: "test/my_app/accounts/user_test.exs", Selected: true},
+		{Path: "test/my_app/accounts/team_test.exs", Failed: true},
+		{Path: "test/my_app_web/controllers/user_controller_test.exs", Changed: true},
+		{Path: "apps/billing/test/billing/invoice_test.exs"},
+	}
+
+	cases := []struct {
+		query string
+		want  []string
+	}{
+		{"@selected", []string{"test/my_app/accounts/user_test.exs"}},
+		{"@unselected @changed", []string{"test/my_app_web/controllers/user_controller_test.exs"}},
+		{"user !controller", []string{"test/my_app/accounts/user_test.exs"}},
+		{"dir:test/my_app/accounts !@failed", []string{"test/my_app/accounts/user_test.exs"}},
+		{"dir:controllers", []string{"test/my_app_web/controllers/user_controller_test.exs"}},
+		{"app:billing", []string{"apps/billing/test/billing/invoice_test.exs"}},
+		{"'team", []string{"test/my_app/accounts/team_test.exs"}},
+		{"'usrctl", nil},
+		{"/(team|invoice)_test/", []string{"test/my_app/accounts/team_test.exs", "apps/billing/test/billing/invoice_test.exs"}},
+		{"/web/ user | invoice", []string{"apps/billing/test/billing/invoice_test.exs", "test/my_app_web/controllers/user_controller_test.exs"}},
+		{"!/accounts/ !/web/", []string{"apps/billing/test/billing/invoice_test.exs"}},
+	}
+
+	for _, tc := range cases {
+		got := mustPaths(t, tc.query, in)
+		if len(got) == 0 && len(tc.want) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("query %q: got %v want %v", tc.query, got, tc.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := map[string]string{
+		"@bogus":         "col 1: unknown filter @bogus",
+		"user /[a-/":     "col 6: invalid regex",
+		"user /unclosed": "col 6: unterminated /regex/",
+		"| user":         "col 1: nothing before |",
+		"user |":         "col 6: nothing after |",
+		"!":              "col 1: ! must be followed by a term",
+		"dir:":           "col 1: dir: needs a directory",
+	}
+
+	for query, want := range cases {
+		_, err := Parse(query)
+		if err == nil {
+			t.Errorf("Parse(%q) succeeded, want error %q", query, want)
+			continue
+		}
+		if !strings.HasPrefix(err.Error(), want) {
+			t.Errorf("Parse(%q) error = %q, want prefix %q", query, err.Error(), want)
+		}
+	}
+}
+
+func TestRegexMayContainSpaces(t *testing.T) {
+	q, err := Parse(`/user test/ !'x`)
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if len(q.alternatives) != 1 || len(q.alternatives[0]) != 2 {
+		t.Fatalf("unexpected parse: %+v", q)
+	}
+}
+
+func TestContentModeMatchesEntries(t *testing.T) {
+	in := []Candidate{
+		{Path: "test/post_controller_test.exs", Content: []ContentEntry{
+			{Text: "MyAppWeb.PostControllerTest", Line: 1},
+			{Text: "show returns 404 when post is missing", Line: 9},
+		}},
+		{Path: "test/user_test.exs", Content: []ContentEntry{
+			{Text: "MyApp.UserTest", Line: 1},
+			{Text: "returns the user", Line: 4},
+		}},
+		{Path: "test/unindexed_test.exs"},
+	}
+
+	matches := mustMatches(t, "# 404 missing", in)
+	if len(matches) != 1 {
+		t.Fatalf("expected one match, got %+v", matches)
+	}
+	m := matches[0]
+	if m.Index != 0 || m.Line != 9 || m.Detail != "show returns 404 when post is missing" {
+		t.Fatalf("unexpected match %+v", m)
+	}
+	if len(m.DetailPositions) != len("404 missing") {
+		t.Fatalf("expected a position per pattern character, got %v", m.DetailPositions)
+	}
+
+	got := mustPaths(t, "#PostControllerTest", in)
+	if want := []string{"test/post_controller_test.exs"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v want %v", got, want)
+	}
+
+	q, err := Parse("  #")
+	if err != nil || !q.ContentMode() || !q.Empty() {
+		t.Fatalf("expected an empty content query, got %+v (%v)", q, err)
+	}
+	if got := mustPaths(t, "#", in); len(got) != 3 {
+		t.Fatalf("an empty content query should match everything, got %v", got)
+	}
+}
@@ -0,0 +1,46 @@
+package testfile
+
+import (
+	"bufio"
+	"strings"
+)
+
+// ChangedTests returns the discovered test files touched by uncommitted
+// changes (staged, unstaged or untracked) plus the tests mapped from changed
+// source files. Outside a git repository it returns nil.
+func ChangedTests(rootDir string, files []TestFile) []string {
+	var changed []string
+	if out, err := gitOutput(rootDir, "diff", "--relative", "--name-only", "HEAD"); err == nil {
+		changed = append(changed, outputLines(out)...)
+	}
+	if out, err := gitOutput(rootDir, "ls-files", "--others", "--exclude-standard"); err == nil {
+		changed = append(changed, outputLines(out)...)
+	}
+
+	var tests []string
+	seen := map[string]struct{}{}
+	for _, path := range changed {
+		if !strings.HasSuffix(path, ".ex") && !strings.HasSuffix(path, ".exs") {
+			continue
+		}
+		for _, test := range TestsForPath(path, files) {
+			if _, ok := seen[test]; ok {
+				continue
+			}
+			seen[test] = struct{}{}
+			tests = append(tests, test)
+		}
+	}
+	return tests
+}
+
+func outputLines(output string) []string {
+	var lines []string
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,25 @@
+package testfile
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChangedTestsMapsSourcesAndSkipsOtherFiles(t *testing.T) {
+	stubGit(t, map[string]string{
+		"diff":     "lib/app/user.ex\ntest/app/team_test.exs\nmix.lock\n",
+		"ls-files": "test/app/new_test.exs\nREADME.md\n",
+	})
+	files := []TestFile{
+		{Path: "test/app/user_test.exs"},
+		{Path: "test/app/team_test.exs"},
+		{Path: "test/app/new_test.exs"},
+		{Path: "test/app/other_test.exs"},
+	}
+
+	got := ChangedTests("/tmp/project", files)
+	want := []string{"test/app/user_test.exs", "test/app/team_test.exs", "test/app/new_test.exs"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v want %v", got, want)
+	}
+}
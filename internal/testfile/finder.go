@@ -14,61 +14,94 @@ type TestFile struct {
 	AbsolutePath string
 }
 
+// Options controls which directories are scanned and which files are left out.
+type Options struct {
+	// Paths are directories (relative to the project root) to scan. Glob
+	// patterns such as "apps/*/test" are expanded; missing entries are skipped.
+	Paths []string
+	// Exclude holds glob patterns matched against the relative file path.
+	Exclude []string
+}
+
+// DefaultOptions scans the project's test/ directory and umbrella apps.
+func DefaultOptions() Options {
+	return Options{Paths: []string{"test", "apps/*/test"}}
+}
+
 func FindTestFiles(rootDir string) ([]TestFile, error) {
-	testDir := filepath.Join(rootDir, "test")
+	return Discover(rootDir, DefaultOptions())
+}
 
-	info, err := os.Stat(testDir)
-	if os.IsNotExist(err) {
-		return nil, fmt.Errorf("no 'test/' directory found in %s\nAre you in an Elixir/Phoenix project?", rootDir)
+// Discover finds *_test.exs files under the configured test paths.
+func Discover(rootDir string, opts Options) ([]TestFile, error) {
+	if len(opts.Paths) == 0 {
+		opts.Paths = DefaultOptions().Paths
 	}
+
+	testDirs, err := resolveTestDirs(rootDir, opts.Paths)
 	if err != nil {
-		return nil, fmt.Errorf("error accessing test directory: %w", err)
+		return nil, err
 	}
-	if !info.IsDir() {
-		return nil, fmt.Errorf("'test' exists but is not a directory")
+	if len(testDirs) == 0 {
+		return nil, fmt.Errorf("no 'test/' directory found in %s\nAre you in an Elixir/Phoenix project?", rootDir)
 	}
 
 	var testFiles []TestFile
+	seen := map[string]struct{}{}
 
-	err = filepath.WalkDir(testDir, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
+	for _, testDir := range testDirs {
+		err = filepath.WalkDir(testDir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
 
-		if d.IsDir() {
-			if d.Name() == "support" {
-				return filepath.SkipDir
+			if d.IsDir() {
+				if d.Name() == "support" {
+					return filepath.SkipDir
+				}
+				return nil
 			}
-			return nil
-		}
 
-		if !strings.HasSuffix(path, "_test.exs") {
-			return nil
-		}
+			if !strings.HasSuffix(path, "_test.exs") {
+				return nil
+			}
 
-		if d.Name() == "test_helper.exs" {
-			return nil
-		}
+			if d.Name() == "test_helper.exs" {
+				return nil
+			}
 
-		relPath, err := filepath.Rel(rootDir, path)
-		if err != nil {
-			relPath = path
-		}
+			relPath, err := filepath.Rel(rootDir, path)
+			if err != nil {
+				relPath = path
+			}
+			relPath = filepath.ToSlash(relPath)
 
-		testFiles = append(testFiles, TestFile{
-			Path:         relPath,
-			AbsolutePath: path,
-		})
+			if _, ok := seen[relPath]; ok {
+				return nil
+			}
+			seen[relPath] = struct{}{}
 
-		return nil
-	})
+			for _, pattern := range opts.Exclude {
+				if MatchGlob(pattern, relPath) {
+					return nil
+				}
+			}
 
-	if err != nil {
-		return nil, fmt.Errorf("error scanning test directory: %w", err)
+			testFiles = append(testFiles, TestFile{
+				Path:         relPath,
+				AbsolutePath: path,
+			})
+
+			return nil
+		})
+
+		if err != nil {
+			return nil, fmt.Errorf("error scanning test directory: %w", err)
+		}
 	}
 
 	if len(testFiles) == 0 {
-		return nil, fmt.Errorf("no test files (*_test.exs) found in %s", testDir)
+		return nil, fmt.Errorf("no test files (*_test.exs) found in %s", strings.Join(relativeDirs(rootDir, testDirs), ", "))
 	}
 
 	sort.Slice(testFiles, func(i, j int) bool {
@@ -77,3 +110,42 @@ func FindTestFiles(rootDir string) ([]TestFile, error) {
 
 	return testFiles, nil
 }
+
+func resolveTestDirs(rootDir string, paths []string) ([]string, error) {
+	var dirs []string
+	seen := map[string]struct{}{}
+
+	for _, p := range paths {
+		matches, err := filepath.Glob(filepath.Join(rootDir, filepath.FromSlash(p)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid test path %q: %w", p, err)
+		}
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				return nil, fmt.Errorf("error accessing test directory: %w", err)
+			}
+			if !info.IsDir() {
+				continue
+			}
+			if _, ok := seen[match]; ok {
+				continue
+			}
+			seen[match] = struct{}{}
+			dirs = append(dirs, match)
+		}
+	}
+
+	return dirs, nil
+}
+
+func relativeDirs(rootDir string, dirs []string) []string {
+	out := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		if rel, err := filepath.Rel(rootDir, dir); err == nil {
+			dir = rel
+		}
+		out = append(out, dir)
+	}
+	return out
+}
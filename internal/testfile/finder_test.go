@@ -0,0 +1,89 @@
+package testfile
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func touch(t *testing.T, root string, rel string) {
+	t.Helper()
+	path := filepath.Join(root, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", rel, err)
+	}
+}
+
+func paths(files []TestFile) []string {
+	out := make([]string, len(files))
+	for i, f := range files {
+		out[i] = f.Path
+	}
+	return out
+}
+
+func TestDiscoverFindsUmbrellaAppsAndSkipsSupport(t *testing.T) {
+	root := t.TempDir()
+	touch(t, root, "apps/core/test/core_test.exs")
+	touch(t, root, "apps/web/test/web/page_test.exs")
+	touch(t, root, "apps/web/test/support/conn_case_test.exs")
+	touch(t, root, "apps/web/test/test_helper.exs")
+
+	files, err := FindTestFiles(root)
+	if err != nil {
+		t.Fatalf("FindTestFiles returned error: %v", err)
+	}
+	want := []string{"apps/core/test/core_test.exs", "apps/web/test/web/page_test.exs"}
+	if got := paths(files); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected files: got %v want %v", got, want)
+	}
+}
+
+func TestDiscoverAppliesExcludeGlobs(t *testing.T) {
+	root := t.TempDir()
+	touch(t, root, "test/browser/login_test.exs")
+	touch(t, root, "test/unit/user_test.exs")
+
+	files, err := Discover(root, Options{Paths: []string{"test"}, Exclude: []string{"test/browser/**"}})
+	if err != nil {
+		t.Fatalf("Discover returned error: %v", err)
+	}
+	if got, want := paths(files), []string{"test/unit/user_test.exs"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected files: got %v want %v", got, want)
+	}
+}
+
+func TestDiscoverErrorsWithoutTestDir(t *testing.T) {
+	if _, err := FindTestFiles(t.TempDir()); err == nil {
+		t.Fatalf("expected an error when no test directory exists")
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"test/my_app_web/live/**", "test/my_app_web/live/page_live_test.exs", true},
+		{"test/my_app_web/live/**", "test/my_app_web/live/admin/user_live_test.exs", true},
+		{"test/my_app_web/live/**", "test/my_app_web/controllers/page_test.exs", false},
+		{"test/*_test.exs", "test/user_test.exs", true},
+		{"test/*_test.exs", "test/accounts/user_test.exs", false},
+		{"**/user_test.exs", "test/accounts/user_test.exs", true},
+		{"**/user_test.exs", "user_test.exs", true},
+		{"test/accounts", "test/accounts/user_test.exs", true},
+		{"test/accounts", "test/accounts_extra/user_test.exs", false},
+		{"test/user_tes?.exs", "test/user_test.exs", true},
+	}
+
+	for _, tt := range tests {
+		if got := MatchGlob(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("MatchGlob(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
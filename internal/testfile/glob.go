@@ -0,0 +1,82 @@
+package testfile
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var (
+	globCacheMu sync.Mutex
+	globCache   = map[string]*regexp.Regexp{}
+)
+
+// MatchGlob reports whether a slash-separated relative path matches pattern.
+// `*` and `?` stay within one path segment, `**` crosses segments, and a
+// pattern without wildcards also matches everything below that directory.
+func MatchGlob(pattern, path string) bool {
+	pattern = strings.TrimPrefix(strings.TrimSpace(pattern), "./")
+	if pattern == "" {
+		return false
+	}
+
+	if !strings.ContainsAny(pattern, "*?[") {
+		prefix := strings.TrimSuffix(pattern, "/")
+		return path == prefix || strings.HasPrefix(path, prefix+"/")
+	}
+
+	return globRegexp(pattern).MatchString(path)
+}
+
+func globRegexp(pattern string) *regexp.Regexp {
+	globCacheMu.Lock()
+	defer globCacheMu.Unlock()
+
+	if re, ok := globCache[pattern]; ok {
+		return re
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					i++
+					b.WriteString("(?:.*/)?")
+				} else {
+					b.WriteString(".*")
+				}
+				continue
+			}
+			b.WriteString("[^/]*")
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		re = regexp.MustCompile("^" + regexp.QuoteMeta(pattern) + "$")
+	}
+	globCache[pattern] = re
+	return re
+}
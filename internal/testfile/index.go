@@ -0,0 +1,130 @@
+package testfile
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	"github.com/samrobinsonsauce/eztest/internal/fsutil"
+)
+
+const contentIndexVersion = 1
+
+// ContentIndex maps a test file path to its outline.
+type ContentIndex map[string][]OutlineEntry
+
+type contentCache struct {
+	Version int                      `json:"version"`
+	Files   map[string]cachedOutline `json:"files"`
+}
+
+type cachedOutline struct {
+	ModTime int64          `json:"mtime"`
+	Size    int64          `json:"size"`
+	Entries []OutlineEntry `json:"entries"`
+}
+
+// BuildContentIndex reads the outline of every file concurrently. Entries
+// cached at cachePath whose mtime and size are unchanged are reused, and the
+// cache is rewritten afterwards. An empty cachePath disables caching. Files
+// that cannot be read are left out.
+func BuildContentIndex(files []TestFile, cachePath string) ContentIndex {
+	cache := readContentCache(cachePath)
+
+	type result struct {
+		path   string
+		cached cachedOutline
+		ok     bool
+	}
+	jobs := make(chan TestFile)
+	results := make(chan result)
+
+	var wg sync.WaitGroup
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for tf := range jobs {
+				cached, ok := outlineFor(tf, cache)
+				results <- result{path: tf.Path, cached: cached, ok: ok}
+			}
+		}()
+	}
+	go func() {
+		for _, tf := range files {
+			jobs <- tf
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	index := make(ContentIndex, len(files))
+	fresh := contentCache{Version: contentIndexVersion, Files: make(map[string]cachedOutline, len(files))}
+	for r := range results {
+		if !r.ok {
+			continue
+		}
+		index[r.path] = r.cached.Entries
+		fresh.Files[r.path] = r.cached
+	}
+
+	writeContentCache(cachePath, fresh)
+	return index
+}
+
+// outlineFor returns the cached outline when the file is unchanged and
+// parses it otherwise.
+func outlineFor(tf TestFile, cache contentCache) (cachedOutline, bool) {
+	abs := tf.AbsolutePath
+	if abs == "" {
+		abs = tf.Path
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return cachedOutline{}, false
+	}
+
+	if c, ok := cache.Files[tf.Path]; ok && c.ModTime == info.ModTime().UnixNano() && c.Size == info.Size() {
+		return c, true
+	}
+
+	entries, err := FileOutline(abs)
+	if err != nil {
+		return cachedOutline{}, false
+	}
+	return cachedOutline{ModTime: info.ModTime().UnixNano(), Size: info.Size(), Entries: entries}, true
+}
+
+func readContentCache(path string) contentCache {
+	var cache contentCache
+	if path == "" {
+		return cache
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cache
+	}
+	if err := json.Unmarshal(data, &cache); err != nil || cache.Version != contentIndexVersion {
+		return contentCache{}
+	}
+	return cache
+}
+
+// writeContentCache replaces the cache file atomically; failures only cost
+// a re-parse next time, so they are ignored.
+func writeContentCache(path string, cache contentCache) {
+	if path == "" {
+		return
+	}
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+	_ = fsutil.WriteFileAtomic(path, data, 0644)
+}
@@ -0,0 +1,85 @@
+package testfile
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Outline entry kinds.
+const (
+	OutlineModule   = "module"
+	OutlineDescribe = "describe"
+	OutlineTest     = "test"
+)
+
+// OutlineEntry is a module, describe block or test found in a test file.
+type OutlineEntry struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	Line int    `json:"line"`
+	// Describe names the enclosing describe block of a test.
+	Describe string `json:"describe,omitempty"`
+}
+
+// FullName is the name ExUnit reports: tests are prefixed with their
+// describe block.
+func (e OutlineEntry) FullName() string {
+	if e.Describe != "" {
+		return e.Describe + " " + e.Name
+	}
+	return e.Name
+}
+
+var (
+	modulePattern   = regexp.MustCompile(`^\s*defmodule\s+([A-Za-z0-9_.]+)`)
+	describePattern = regexp.MustCompile(`^(\s*)describe\s+"((?:[^"\\]|\\.)*)"`)
+	testPattern     = regexp.MustCompile(`^\s*test\s+"((?:[^"\\]|\\.)*)"`)
+	endPattern      = regexp.MustCompile(`^(\s*)end\b`)
+)
+
+// FileOutline reads the outline of the test file at path.
+func FileOutline(path string) ([]OutlineEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseOutline(f)
+}
+
+// ParseOutline extracts defmodule, describe and test declarations in order.
+// A describe block ends at the first `end` with the same indentation.
+func ParseOutline(r io.Reader) ([]OutlineEntry, error) {
+	var entries []OutlineEntry
+	describe, describeIndent := "", ""
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+
+		if describe != "" {
+			if m := endPattern.FindStringSubmatch(text); m != nil && m[1] == describeIndent {
+				describe, describeIndent = "", ""
+				continue
+			}
+		}
+
+		if m := modulePattern.FindStringSubmatch(text); m != nil {
+			entries = append(entries, OutlineEntry{Kind: OutlineModule, Name: m[1], Line: line})
+		} else if m := describePattern.FindStringSubmatch(text); m != nil {
+			describe, describeIndent = unescape(m[2]), m[1]
+			entries = append(entries, OutlineEntry{Kind: OutlineDescribe, Name: describe, Line: line})
+		} else if m := testPattern.FindStringSubmatch(text); m != nil {
+			entries = append(entries, OutlineEntry{Kind: OutlineTest, Name: unescape(m[1]), Line: line, Describe: describe})
+		}
+	}
+	return entries, scanner.Err()
+}
+
+func unescape(s string) string {
+	return strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(s)
+}
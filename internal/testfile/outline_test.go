@@ -0,0 +1,90 @@
+package testfile
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sampleTest = `defmodule MyAppWeb.PostControllerTest do
+  use MyAppWeb.ConnCase
+
+  test "lists posts", %{conn: conn} do
+    assert conn
+  end
+
+  describe "show" do
+    test "returns 404 when post is missing" do
+      :ok
+    end
+
+    test "renders \"draft\" posts" do
+    end
+  end
+
+  test "outside describe" do
+  end
+end
+`
+
+func TestParseOutline(t *testing.T) {
+	got, err := ParseOutline(strings.NewReader(sampleTest))
+	if err != nil {
+		t.Fatalf("ParseOutline returned error: %v", err)
+	}
+
+	want := []OutlineEntry{
+		{Kind: OutlineModule, Name: "MyAppWeb.PostControllerTest", Line: 1},
+		{Kind: OutlineTest, Name: "lists posts", Line: 4},
+		{Kind: OutlineDescribe, Name: "show", Line: 8},
+		{Kind: OutlineTest, Name: "returns 404 when post is missing", Line: 9, Describe: "show"},
+		{Kind: OutlineTest, Name: `renders "draft" posts`, Line: 13, Describe: "show"},
+		{Kind: OutlineTest, Name: "outside describe", Line: 17},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected outline:\n got %+v\nwant %+v", got, want)
+	}
+	if name := got[3].FullName(); name != "show returns 404 when post is missing" {
+		t.Fatalf("unexpected full name %q", name)
+	}
+}
+
+func TestBuildContentIndexUsesCacheUntilFileChanges(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "post_test.exs")
+	if err := os.WriteFile(path, []byte(sampleTest), 0644); err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+	files := []TestFile{{Path: "test/post_test.exs", AbsolutePath: path}}
+	cachePath := filepath.Join(dir, "cache", "outline.json")
+
+	index := BuildContentIndex(files, cachePath)
+	if len(index["test/post_test.exs"]) != 6 {
+		t.Fatalf("expected 6 entries, got %+v", index)
+	}
+	if _, err := os.Stat(cachePath); err != nil {
+		t.Fatalf("expected cache file: %v", err)
+	}
+
+	// Poison the cached entry: it must be served while the file is unchanged.
+	cache := readContentCache(cachePath)
+	entry := cache.Files["test/post_test.exs"]
+	entry.Entries = []OutlineEntry{{Kind: OutlineModule, Name: "Cached", Line: 1}}
+	cache.Files["test/post_test.exs"] = entry
+	writeContentCache(cachePath, cache)
+
+	if got := BuildContentIndex(files, cachePath)["test/post_test.exs"]; len(got) != 1 || got[0].Name != "Cached" {
+		t.Fatalf("expected cached outline, got %+v", got)
+	}
+
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatalf("Chtimes returned error: %v", err)
+	}
+	if got := BuildContentIndex(files, cachePath)["test/post_test.exs"]; len(got) != 6 {
+		t.Fatalf("expected re-parsed outline after mtime change, got %+v", got)
+	}
+}
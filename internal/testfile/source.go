@@ -0,0 +1,66 @@
+package testfile
+
+import (
+	"path"
+	"strings"
+)
+
+// TestsForPath maps a path taken from git, grep or CI output to discovered
+// test files. Test files map to themselves, Elixir source files under lib/
+// map to the test at the mirrored path (lib/app/user.ex ->
+// test/app/user_test.exs, including umbrella apps), falling back to tests
+// with the same base name. Anything else is treated as a directory or glob.
+// The result keeps the order of files; nil means nothing matched.
+func TestsForPath(p string, files []TestFile) []string {
+	p = strings.TrimPrefix(path.Clean(strings.ReplaceAll(p, "\\", "/")), "./")
+
+	for _, tf := range files {
+		if tf.Path == p {
+			return []string{tf.Path}
+		}
+	}
+
+	if strings.HasSuffix(p, ".ex") {
+		return testsForSource(p, files)
+	}
+	if strings.HasSuffix(p, ".exs") && !strings.ContainsAny(p, "*?[") {
+		return nil
+	}
+
+	var out []string
+	for _, tf := range files {
+		if MatchGlob(p, tf.Path) {
+			out = append(out, tf.Path)
+		}
+	}
+	return out
+}
+
+func testsForSource(source string, files []TestFile) []string {
+	testName := strings.TrimSuffix(source, ".ex") + "_test.exs"
+
+	var mirrored string
+	switch {
+	case strings.HasPrefix(testName, "lib/"):
+		mirrored = "test/" + strings.TrimPrefix(testName, "lib/")
+	case strings.Contains(testName, "/lib/"):
+		i := strings.Index(testName, "/lib/")
+		mirrored = testName[:i] + "/test/" + testName[i+len("/lib/"):]
+	}
+	if mirrored != "" {
+		for _, tf := range files {
+			if tf.Path == mirrored {
+				return []string{tf.Path}
+			}
+		}
+	}
+
+	base := path.Base(testName)
+	var out []string
+	for _, tf := range files {
+		if path.Base(tf.Path) == base {
+			out = append(out, tf.Path)
+		}
+	}
+	return out
+}
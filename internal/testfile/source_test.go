@@ -0,0 +1,37 @@
+package testfile
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTestsForPath(t *testing.T) {
+	files := []TestFile{
+		{Path: "test/my_app/accounts/user_test.exs"},
+		{Path: "test/my_app/accounts/team_test.exs"},
+		{Path: "test/my_app_web/controllers/user_controller_test.exs"},
+		{Path: "test/legacy/user_controller_test.exs"},
+		{Path: "apps/billing/test/billing/invoice_test.exs"},
+	}
+
+	cases := []struct {
+		in   string
+		want []string
+	}{
+		{"./test/my_app/accounts/user_test.exs", []string{"test/my_app/accounts/user_test.exs"}},
+		{"lib/my_app/accounts/user.ex", []string{"test/my_app/accounts/user_test.exs"}},
+		{"apps/billing/lib/billing/invoice.ex", []string{"apps/billing/test/billing/invoice_test.exs"}},
+		{"lib/other/user_controller.ex", []string{"test/my_app_web/controllers/user_controller_test.exs", "test/legacy/user_controller_test.exs"}},
+		{"test/my_app/accounts", []string{"test/my_app/accounts/user_test.exs", "test/my_app/accounts/team_test.exs"}},
+		{"test/**/team_test.exs", []string{"test/my_app/accounts/team_test.exs"}},
+		{"test/missing_test.exs", nil},
+		{"lib/my_app/repo.ex", nil},
+		{"mix.lock", nil},
+	}
+
+	for _, tc := range cases {
+		if got := TestsForPath(tc.in, files); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("TestsForPath(%q) = %v, want %v", tc.in, got, tc.want)
+		}
+	}
+}
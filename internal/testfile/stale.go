@@ -0,0 +1,122 @@
+package testfile
+
+import (
+	"bufio"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// Resolution describes how a list of saved paths maps onto the test files
+// currently on disk.
+type Resolution struct {
+	// Paths holds every saved path that still resolves, with renamed
+	// entries replaced by their new location. Order is preserved.
+	Paths []string
+	// Renamed maps an old saved path to the path git says it moved to.
+	Renamed map[string]string
+	// Missing holds saved paths that no longer exist and could not be
+	// followed through a rename.
+	Missing []string
+}
+
+// Changed reports whether the resolved paths differ from the saved input.
+func (r Resolution) Changed() bool {
+	return len(r.Renamed) > 0 || len(r.Missing) > 0
+}
+
+var gitOutput = func(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+// ResolveSaved validates saved paths against the discovered test files.
+// Paths that disappeared are followed through git renames (committed and
+// uncommitted) when the project lives in a git repository.
+func ResolveSaved(rootDir string, saved []string, files []TestFile) Resolution {
+	res := Resolution{
+		Paths:   make([]string, 0, len(saved)),
+		Renamed: map[string]string{},
+		Missing: []string{},
+	}
+
+	known := make(map[string]struct{}, len(files))
+	for _, tf := range files {
+		known[tf.Path] = struct{}{}
+	}
+
+	var renames map[string]string
+	seen := make(map[string]struct{}, len(saved))
+	for _, path := range saved {
+		resolved := path
+		if _, ok := known[path]; !ok {
+			if renames == nil {
+				renames = gitRenames(rootDir)
+			}
+			target, ok := followRename(renames, path)
+			if _, exists := known[target]; !ok || !exists {
+				res.Missing = append(res.Missing, path)
+				continue
+			}
+			res.Renamed[path] = target
+			resolved = target
+		}
+
+		if _, dup := seen[resolved]; dup {
+			continue
+		}
+		seen[resolved] = struct{}{}
+		res.Paths = append(res.Paths, resolved)
+	}
+
+	sort.Strings(res.Missing)
+	return res
+}
+
+func followRename(renames map[string]string, path string) (string, bool) {
+	current := path
+	visited := map[string]struct{}{current: {}}
+	for {
+		next, ok := renames[current]
+		if !ok {
+			break
+		}
+		if _, loop := visited[next]; loop {
+			break
+		}
+		visited[next] = struct{}{}
+		current = next
+	}
+	return current, current != path
+}
+
+// gitRenames returns old path -> new path for renames recorded in the
+// working tree and in history, relative to rootDir. Newer renames win.
+func gitRenames(rootDir string) map[string]string {
+	renames := map[string]string{}
+
+	if out, err := gitOutput(rootDir, "diff", "-M", "--relative", "--name-status", "HEAD"); err == nil {
+		parseRenames(out, renames)
+	}
+	if out, err := gitOutput(rootDir, "log", "-M", "--relative", "--diff-filter=R", "--name-status", "--format="); err == nil {
+		parseRenames(out, renames)
+	}
+
+	return renames
+}
+
+func parseRenames(output string, into map[string]string) {
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) != 3 || !strings.HasPrefix(fields[0], "R") {
+			continue
+		}
+		from, to := fields[1], fields[2]
+		if _, ok := into[from]; ok {
+			continue
+		}
+		into[from] = to
+	}
+}
@@ -0,0 +1,76 @@
+package testfile
+
+import (
+	"reflect"
+	"testing"
+)
+
+func stubGit(t *testing.T, outputs map[string]string) {
+	t.Helper()
+	original := gitOutput
+	gitOutput = func(dir string, args ...string) (string, error) {
+		return outputs[args[0]], nil
+	}
+	t.Cleanup(func() {
+		gitOutput = original
+	})
+}
+
+func TestResolveSavedKeepsExistingPaths(t *testing.T) {
+	stubGit(t, nil)
+	files := []TestFile{{Path: "test/a_test.exs"}, {Path: "test/b_test.exs"}}
+
+	res := ResolveSaved("/tmp/project", []string{"test/b_test.exs", "test/a_test.exs"}, files)
+	if res.Changed() {
+		t.Fatalf("expected no changes, got %+v", res)
+	}
+	if want := []string{"test/b_test.exs", "test/a_test.exs"}; !reflect.DeepEqual(res.Paths, want) {
+		t.Fatalf("unexpected paths: got %v want %v", res.Paths, want)
+	}
+}
+
+func TestResolveSavedFollowsRenameChains(t *testing.T) {
+	stubGit(t, map[string]string{
+		"diff": "R100\ttest/b_test.exs\ttest/c_test.exs\n",
+		"log":  "M\ttest/other_test.exs\nR092\ttest/a_test.exs\ttest/b_test.exs\n",
+	})
+	files := []TestFile{{Path: "test/c_test.exs"}}
+
+	res := ResolveSaved("/tmp/project", []string{"test/a_test.exs"}, files)
+	if want := []string{"test/c_test.exs"}; !reflect.DeepEqual(res.Paths, want) {
+		t.Fatalf("unexpected paths: got %v want %v", res.Paths, want)
+	}
+	if got := res.Renamed["test/a_test.exs"]; got != "test/c_test.exs" {
+		t.Fatalf("expected rename to test/c_test.exs, got %q", got)
+	}
+	if len(res.Missing) != 0 {
+		t.Fatalf("expected no missing paths, got %v", res.Missing)
+	}
+}
+
+func TestResolveSavedReportsUnresolvedPaths(t *testing.T) {
+	stubGit(t, map[string]string{
+		"log": "R100\ttest/gone_test.exs\ttest/also_gone_test.exs\n",
+	})
+	files := []TestFile{{Path: "test/a_test.exs"}}
+
+	res := ResolveSaved("/tmp/project", []string{"test/a_test.exs", "test/gone_test.exs", "test/deleted_test.exs"}, files)
+	if want := []string{"test/a_test.exs"}; !reflect.DeepEqual(res.Paths, want) {
+		t.Fatalf("unexpected paths: got %v want %v", res.Paths, want)
+	}
+	if want := []string{"test/deleted_test.exs", "test/gone_test.exs"}; !reflect.DeepEqual(res.Missing, want) {
+		t.Fatalf("unexpected missing paths: got %v want %v", res.Missing, want)
+	}
+}
+
+func TestResolveSavedDropsDuplicatesAfterRename(t *testing.T) {
+	stubGit(t, map[string]string{
+		"log": "R100\ttest/old_test.exs\ttest/new_test.exs\n",
+	})
+	files := []TestFile{{Path: "test/new_test.exs"}}
+
+	res := ResolveSaved("/tmp/project", []string{"test/new_test.exs", "test/old_test.exs"}, files)
+	if want := []string{"test/new_test.exs"}; !reflect.DeepEqual(res.Paths, want) {
+		t.Fatalf("unexpected paths: got %v want %v", res.Paths, want)
+	}
+}
@@ -0,0 +1,95 @@
+package testfile
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var (
+	atomTagPattern    = regexp.MustCompile(`@(?:module|describe)?tag\s+:([A-Za-z_][A-Za-z0-9_?!]*)`)
+	keywordTagPattern = regexp.MustCompile(`@(?:module|describe)?tag\s+\[?\s*([A-Za-z_][A-Za-z0-9_?!]*):`)
+)
+
+// FileTags returns the ExUnit tags (@tag, @describetag, @moduletag) used in
+// a test file, in order of first appearance.
+func FileTags(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var tags []string
+	seen := map[string]struct{}{}
+	add := func(tag string) {
+		if _, ok := seen[tag]; ok {
+			return
+		}
+		seen[tag] = struct{}{}
+		tags = append(tags, tag)
+	}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "@") {
+			continue
+		}
+		for _, m := range atomTagPattern.FindAllStringSubmatch(line, -1) {
+			add(m[1])
+		}
+		for _, m := range keywordTagPattern.FindAllStringSubmatch(line, -1) {
+			add(m[1])
+		}
+	}
+
+	return tags, scanner.Err()
+}
+
+// SelectMatching returns the paths of files matching any of the given exact
+// paths, glob patterns or ExUnit tags, in discovery order.
+func SelectMatching(files []TestFile, paths, globs, tags []string) []string {
+	exact := make(map[string]struct{}, len(paths))
+	for _, p := range paths {
+		exact[strings.TrimPrefix(p, "./")] = struct{}{}
+	}
+	wantTags := make(map[string]struct{}, len(tags))
+	for _, t := range tags {
+		wantTags[strings.TrimPrefix(t, ":")] = struct{}{}
+	}
+
+	var out []string
+	for _, tf := range files {
+		if matchesRule(tf, exact, globs, wantTags) {
+			out = append(out, tf.Path)
+		}
+	}
+	return out
+}
+
+func matchesRule(tf TestFile, exact map[string]struct{}, globs []string, tags map[string]struct{}) bool {
+	if _, ok := exact[tf.Path]; ok {
+		return true
+	}
+	for _, pattern := range globs {
+		if MatchGlob(pattern, tf.Path) {
+			return true
+		}
+	}
+	if len(tags) == 0 || tf.AbsolutePath == "" {
+		return false
+	}
+
+	fileTags, err := FileTags(tf.AbsolutePath)
+	if err != nil {
+		return false
+	}
+	for _, tag := range fileTags {
+		if _, ok := tags[tag]; ok {
+			return true
+		}
+	}
+	return false
+}
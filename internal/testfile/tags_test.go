@@ -0,0 +1,57 @@
+package testfile
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFileTagsReadsModuleDescribeAndTestTags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "user_test.exs")
+	contents := `defmodule MyApp.UserTest do
+  use ExUnit.Case
+  @moduletag :integration
+  @moduletag capture_log: true
+
+  describe "create" do
+    @describetag :slow
+    @tag :integration
+    test "works" do
+    end
+  end
+end
+`
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	tags, err := FileTags(path)
+	if err != nil {
+		t.Fatalf("FileTags returned error: %v", err)
+	}
+	if want := []string{"integration", "capture_log", "slow"}; !reflect.DeepEqual(tags, want) {
+		t.Fatalf("FileTags() = %v, want %v", tags, want)
+	}
+}
+
+func TestSelectMatchingCombinesPathsGlobsAndTags(t *testing.T) {
+	root := t.TempDir()
+	touch(t, root, "test/a_test.exs")
+	touch(t, root, "test/live/b_test.exs")
+	tagged := filepath.Join(root, "test", "c_test.exs")
+	if err := os.WriteFile(tagged, []byte("@moduletag :smoke\n"), 0644); err != nil {
+		t.Fatalf("failed to write tagged file: %v", err)
+	}
+
+	files, err := FindTestFiles(root)
+	if err != nil {
+		t.Fatalf("FindTestFiles returned error: %v", err)
+	}
+
+	got := SelectMatching(files, []string{"test/a_test.exs"}, []string{"test/live/**"}, []string{":smoke"})
+	want := []string{"test/a_test.exs", "test/c_test.exs", "test/live/b_test.exs"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("SelectMatching() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,110 @@
+package tui
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// CustomAction is a command from the config's "actions" that the TUI can
+// run, bound to its own keys and listed in the command palette.
+type CustomAction struct {
+	Name        string
+	Description string
+	Command     string
+	Keys        []string
+}
+
+type customActionFinishedMsg struct {
+	title string
+	err   error
+}
+
+// WithCustomActions adds custom actions to the key map, the help overlay and
+// the command palette. Actions named like a built-in action are skipped.
+func (m Model) WithCustomActions(actions []CustomAction) Model {
+	m.keyMap = m.keyMap.WithCustomActions(actions)
+	m.customActions = make(map[string]CustomAction, len(actions))
+	for _, a := range actions {
+		m.customActions[a.Name] = a
+	}
+	return m
+}
+
+// WithCustomActions returns the key map with a binding for every custom
+// action. Actions named like a built-in action are skipped.
+func (k KeyMap) WithCustomActions(actions []CustomAction) KeyMap {
+	builtin := defaultBindings()
+	k.custom = nil
+	k.sequences = append([]Sequence(nil), k.sequences...)
+	for _, a := range actions {
+		if _, ok := builtin[a.Name]; ok || a.Name == "" {
+			continue
+		}
+		keys := cleanBindingKeys(a.Keys)
+		for _, key := range keys {
+			if isSequence(key) {
+				k.sequences = append(k.sequences, Sequence{Action: a.Name, Keys: strings.Split(key, " ")})
+			}
+		}
+		k.custom = append(k.custom, ActionBinding{Action: a.Name, Binding: makeBinding(keys, a.title())})
+	}
+	return k
+}
+
+// title is how the action is described in help and the palette.
+func (a CustomAction) title() string {
+	if a.Description != "" {
+		return a.Description
+	}
+	return a.Name
+}
+
+// runCustomAction suspends the TUI and runs the action's command in the
+// project directory.
+func (m *Model) runCustomAction(a CustomAction) tea.Cmd {
+	cursorFile := ""
+	if i := m.cursorItem(); i >= 0 {
+		cursorFile = m.filteredItems[i].TestFile.Path
+	}
+	argv, err := CustomCommand(a.Command, m.getSelectedFiles(), cursorFile)
+	if err != nil {
+		m.notice = err.Error()
+		return nil
+	}
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Dir = m.projectDir
+	title := a.title()
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return customActionFinishedMsg{title: title, err: err}
+	})
+}
+
+// CustomCommand builds a custom action's command. A word that is exactly
+// {files} becomes one argument per selected file, and {file} in any word is
+// replaced with the file under the cursor.
+func CustomCommand(template string, selected []string, cursorFile string) ([]string, error) {
+	var argv []string
+	for _, word := range strings.Fields(template) {
+		switch {
+		case word == "{files}":
+			if len(selected) == 0 {
+				return nil, fmt.Errorf("select files to run %q", template)
+			}
+			argv = append(argv, selected...)
+		case strings.Contains(word, "{file}"):
+			if cursorFile == "" {
+				return nil, fmt.Errorf("move the cursor to a file to run %q", template)
+			}
+			argv = append(argv, strings.ReplaceAll(word, "{file}", cursorFile))
+		default:
+			argv = append(argv, word)
+		}
+	}
+	if len(argv) == 0 {
+		return nil, fmt.Errorf("custom action has no command")
+	}
+	return argv, nil
+}
@@ -0,0 +1,52 @@
+package tui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestCustomCommandExpandsFiles(t *testing.T) {
+	got, err := CustomCommand("mix credo --strict {files}", []string{"test/a_test.exs", "test/b_test.exs"}, "")
+	if want := []string{"mix", "credo", "--strict", "test/a_test.exs", "test/b_test.exs"}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected command %v (%v)", got, err)
+	}
+
+	got, err = CustomCommand("git log -- {file}", nil, "test/a_test.exs")
+	if want := []string{"git", "log", "--", "test/a_test.exs"}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected command %v (%v)", got, err)
+	}
+
+	if _, err := CustomCommand("mix format {files}", nil, "test/a_test.exs"); err == nil {
+		t.Fatal("expected {files} without a selection to fail")
+	}
+}
+
+func TestCustomActionsAreBoundAndListed(t *testing.T) {
+	m := testModelForOverlay(t).WithCustomActions([]CustomAction{
+		{Name: "credo", Description: "credo on selection", Command: "mix credo {files}", Keys: []string{"alt+c"}},
+		{Name: "up", Command: "true"},
+	})
+
+	if action := m.keyMap.Action(altKey('c')); action != "credo" {
+		t.Fatalf("expected alt+c to run the custom action, got %q", action)
+	}
+	if action := m.keyMap.Action(tea.KeyMsg{Type: tea.KeyUp}); action != actionUp {
+		t.Fatalf("expected a custom action named like a built-in to be skipped, got %q", action)
+	}
+	if cmd := m.handleAction("credo"); cmd == nil {
+		t.Fatalf("expected the custom action to run a command, got notice %q", m.notice)
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlP})
+	m = typeRunes(updated.(Model), "credo")
+	if len(m.paletteMatches) == 0 || m.paletteMatches[0].action != "credo" || m.paletteMatches[0].key != "alt+c" {
+		t.Fatalf("expected the palette to list the custom action with its key, got %+v", m.paletteMatches)
+	}
+
+	if help := m.keyMap.HelpText(""); !strings.Contains(help, "Custom actions:") || !strings.Contains(help, "credo on selection") {
+		t.Fatalf("expected the help to list custom actions, got:\n%s", help)
+	}
+}
@@ -0,0 +1,80 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/samrobinsonsauce/eztest/internal/testfile"
+)
+
+// invertVisible flips the selection of every file in the filtered view.
+func (m *Model) invertVisible() {
+	n := m.changeSelection("invert", func() {
+		for i := range m.filteredItems {
+			m.setItemSelected(i, !m.filteredItems[i].Selected)
+		}
+	})
+	m.notice = fmt.Sprintf("Inverted the visible selection (%s changed)", filesLabel(n))
+}
+
+// deselectVisible deselects the files in the filtered view, keeping the
+// selection of files the filter hides.
+func (m *Model) deselectVisible() {
+	n := m.changeSelection("deselect visible", func() {
+		for i := range m.filteredItems {
+			m.setItemSelected(i, false)
+		}
+	})
+	m.notice = fmt.Sprintf("Deselected the visible files (%s changed)", filesLabel(n))
+}
+
+// setMark remembers the file under the cursor as one end of a range.
+func (m *Model) setMark() {
+	i := m.cursorItem()
+	if i < 0 {
+		return
+	}
+	m.mark = m.filteredItems[i].TestFile.Path
+	m.notice = "Marked " + m.mark
+}
+
+// selectMarkedRange selects every row between the mark and the cursor.
+func (m *Model) selectMarkedRange() {
+	if m.mark == "" {
+		m.notice = "No mark set. Mark a file with " + m.keyMap.Mark.Help().Key
+		return
+	}
+	markRow := -1
+	for row := 0; row < m.rowCount(); row++ {
+		if item := m.rowItem(row); item >= 0 && m.filteredItems[item].TestFile.Path == m.mark {
+			markRow = row
+			break
+		}
+	}
+	if markRow < 0 {
+		m.notice = m.mark + " is not in the list"
+		return
+	}
+
+	start, end := min(markRow, m.cursor), max(markRow, m.cursor)
+	n := m.changeSelection("range selection", func() {
+		for row := start; row <= end; row++ {
+			for _, i := range m.itemsOnRow(row) {
+				m.setItemSelected(i, true)
+			}
+		}
+	})
+	m.notice = fmt.Sprintf("Selected the marked range (%s changed)", filesLabel(n))
+}
+
+// selectGlob selects every file matching pattern, including files the
+// filter leaves out but not hidden files.
+func (m *Model) selectGlob(pattern string) {
+	n := m.changeSelection("glob selection", func() {
+		for _, item := range m.allItems {
+			if !item.Hidden && testfile.MatchGlob(pattern, item.TestFile.Path) {
+				m.setPathSelected(item.TestFile.Path, true)
+			}
+		}
+	})
+	m.notice = fmt.Sprintf("Selected %s (%s changed)", pattern, filesLabel(n))
+}
@@ -0,0 +1,85 @@
+package tui
+
+import (
+	"reflect"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func testModelForBulk(t *testing.T) Model {
+	return newTestModel(t, []string{
+		"test/my_app/accounts_test.exs",
+		"test/my_app/billing_test.exs",
+		"test/my_app_web/live/page_live_test.exs",
+		"test/my_app_web/live/user_live_test.exs",
+		"test/my_app_web/router_test.exs",
+	}, withSelected("test/my_app/accounts_test.exs", "test/my_app_web/router_test.exs"))
+}
+
+func altKey(r rune) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}, Alt: true}
+}
+
+func TestInvertAndDeselectVisible(t *testing.T) {
+	m := testModelForBulk(t)
+	m.searchInput.SetValue("dir:test/my_app")
+	m.updateFilter()
+
+	updated, _ := m.Update(altKey('i'))
+	m = updated.(Model)
+	want := []string{"test/my_app/billing_test.exs", "test/my_app_web/router_test.exs"}
+	if got := m.getSelectedFiles(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected invert to flip only the visible files, got %v", got)
+	}
+	if m.notice != "Inverted the visible selection (2 files changed)" {
+		t.Fatalf("unexpected notice %q", m.notice)
+	}
+
+	updated, _ = m.Update(altKey('d'))
+	m = updated.(Model)
+	if got := m.getSelectedFiles(); !reflect.DeepEqual(got, []string{"test/my_app_web/router_test.exs"}) {
+		t.Fatalf("expected hidden selections to survive deselect visible, got %v", got)
+	}
+
+	m.undo()
+	if got := m.getSelectedFiles(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected deselect visible to be undoable, got %v", got)
+	}
+}
+
+func TestSelectMarkedRangeAndGlob(t *testing.T) {
+	m := testModelForBulk(t)
+	m.applySelection(nil)
+
+	updated, _ := m.Update(altKey('r'))
+	if m = updated.(Model); m.notice == "" || len(m.getSelectedFiles()) != 0 {
+		t.Fatal("expected a notice when no mark is set")
+	}
+
+	m.cursor = 3
+	updated, _ = m.Update(altKey('m'))
+	m = updated.(Model)
+	m.cursor = 1
+	updated, _ = m.Update(altKey('r'))
+	m = updated.(Model)
+	want := []string{"test/my_app/billing_test.exs", "test/my_app_web/live/page_live_test.exs", "test/my_app_web/live/user_live_test.exs"}
+	if got := m.getSelectedFiles(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected the marked range to be selected, got %v", got)
+	}
+
+	m.applySelection(nil)
+	m.searchInput.SetValue("accounts")
+	m.updateFilter()
+	updated, _ = m.Update(altKey('g'))
+	m = typeRunes(updated.(Model), "test/my_app_web/**")
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	want = []string{"test/my_app_web/live/page_live_test.exs", "test/my_app_web/live/user_live_test.exs", "test/my_app_web/router_test.exs"}
+	if got := m.getSelectedFiles(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected the glob to select hidden files too, got %v", got)
+	}
+	if m.notice != "Selected test/my_app_web/** (3 files changed)" {
+		t.Fatalf("unexpected notice %q", m.notice)
+	}
+}
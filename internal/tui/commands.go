@@ -12,6 +12,7 @@ import (
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/samrobinsonsauce/eztest/internal/config"
 )
 
 func runTestsCmd(files []string) tea.Cmd {
@@ -26,9 +27,15 @@ type runTestsMsg struct {
 
 type TestRunOutcome struct {
 	FailedFiles []string
+	// FailureMessages holds the ExUnit failure blocks reported for each
+	// failed file.
+	FailureMessages map[string]string
 }
 
-var ansiEscapePattern = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+var (
+	ansiEscapePattern    = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+	failureHeaderPattern = regexp.MustCompile(`^(\s*)\d+\) `)
+)
 
 func PrintRunBanner(files []string) {
 	logo := `
@@ -65,7 +72,9 @@ func PrintRunBanner(files []string) {
 	fmt.Println()
 }
 
-func ExecuteMixTest(files []string) (TestRunOutcome, error) {
+// ExecuteMixTest runs the configured test command (`mix test` by default)
+// with the given files appended and reports which of them failed.
+func ExecuteMixTest(run config.RunSettings, files []string) (TestRunOutcome, error) {
 	outcome := TestRunOutcome{FailedFiles: []string{}}
 	if len(files) == 0 {
 		return outcome, nil
@@ -73,16 +82,22 @@ func ExecuteMixTest(files []string) (TestRunOutcome, error) {
 
 	PrintRunBanner(files)
 
-	mixPath, err := exec.LookPath("mix")
+	command := run.Command
+	if len(command) == 0 {
+		command = config.DefaultAppSettings().Run.Command
+	}
+
+	binPath, err := exec.LookPath(command[0])
 	if err != nil {
 		return outcome, err
 	}
 
-	args := make([]string, 0, len(files)+1)
-	args = append(args, "test")
+	args := make([]string, 0, len(command)+len(run.Args)+len(files))
+	args = append(args, command[1:]...)
+	args = append(args, run.Args...)
 	args = append(args, files...)
 
-	cmd := exec.Command(mixPath, args...)
+	cmd := exec.Command(binPath, args...)
 	cmd.Stdin = os.Stdin
 	cmd.Env = os.Environ()
 
@@ -92,6 +107,7 @@ func ExecuteMixTest(files []string) (TestRunOutcome, error) {
 
 	err = cmd.Run()
 	outcome.FailedFiles = extractFailedFiles(output.String(), files)
+	outcome.FailureMessages = extractFailureMessages(output.String(), files)
 	if err != nil {
 		var exitErr *exec.ExitError
 		if errors.As(err, &exitErr) && len(outcome.FailedFiles) == 0 {
@@ -141,6 +157,57 @@ func extractFailedFiles(output string, runFiles []string) []string {
 	return failures
 }
 
+// extractFailureMessages splits ExUnit output into its numbered failure
+// blocks ("  1) test ...") and groups them by the run file each one names.
+func extractFailureMessages(output string, runFiles []string) map[string]string {
+	output = ansiEscapePattern.ReplaceAllString(output, "")
+
+	var blocks [][]string
+	var current []string
+	indent := ""
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if m := failureHeaderPattern.FindStringSubmatch(line); m != nil {
+			if current != nil {
+				blocks = append(blocks, current)
+			}
+			indent = m[1]
+			current = []string{strings.TrimPrefix(line, indent)}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if strings.TrimSpace(line) != "" && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+			blocks = append(blocks, current)
+			current = nil
+			continue
+		}
+		current = append(current, strings.TrimPrefix(line, indent))
+	}
+	if current != nil {
+		blocks = append(blocks, current)
+	}
+
+	messages := map[string]string{}
+	for _, block := range blocks {
+		for len(block) > 0 && strings.TrimSpace(block[len(block)-1]) == "" {
+			block = block[:len(block)-1]
+		}
+		files := extractFailedFiles(strings.Join(block, "\n"), runFiles)
+		if len(files) == 0 {
+			continue
+		}
+		file := files[0]
+		if prev, ok := messages[file]; ok {
+			messages[file] = prev + "\n\n" + strings.Join(block, "\n")
+		} else {
+			messages[file] = strings.Join(block, "\n")
+		}
+	}
+	return messages
+}
+
 func pathFromToken(token string) string {
 	token = strings.TrimSpace(token)
 	if token == "" {
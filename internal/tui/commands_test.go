@@ -42,3 +42,35 @@ func TestExtractFailedFilesReturnsEmptyWhenNoMatches(t *testing.T) {
 		t.Fatalf("expected no failed files, got %v", got)
 	}
 }
+
+func TestExtractFailureMessagesGroupsBlocksByFile(t *testing.T) {
+	output := "\x1b[31m" + `
+  1) test rejects an expired token (MyApp.AuthTest)
+     test/auth_test.exs:12
+     Assertion with == failed
+     left:  :ok
+     right: :error
+
+  2) test creates a user (MyApp.UserTest)
+     test/user_test.exs:8
+     ** (RuntimeError) boom
+
+  3) test rejects a missing token (MyApp.AuthTest)
+     test/auth_test.exs:20
+     Expected truthy, got false
+
+
+Finished in 0.1 seconds
+3 tests, 3 failures
+`
+
+	got := extractFailureMessages(output, []string{"test/auth_test.exs", "test/user_test.exs"})
+	want := map[string]string{
+		"test/auth_test.exs": "1) test rejects an expired token (MyApp.AuthTest)\n   test/auth_test.exs:12\n   Assertion with == failed\n   left:  :ok\n   right: :error" +
+			"\n\n3) test rejects a missing token (MyApp.AuthTest)\n   test/auth_test.exs:20\n   Expected truthy, got false",
+		"test/user_test.exs": "2) test creates a user (MyApp.UserTest)\n   test/user_test.exs:8\n   ** (RuntimeError) boom",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("extractFailureMessages() =\n%#v\nwant\n%#v", got, want)
+	}
+}
@@ -1,6 +1,11 @@
 package tui
 
 import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
 	"github.com/samrobinsonsauce/eztest/internal/testfile"
 )
 
@@ -8,6 +13,20 @@ type Item struct {
 	TestFile testfile.TestFile
 	Selected bool
 	Failed   bool
+	// Changed marks files touched by uncommitted changes (@changed).
+	Changed bool
+	// Pinned files sort to the top of the list. Hidden files are left out
+	// of it unless the query contains @hidden.
+	Pinned bool
+	Hidden bool
+	// MatchedIndexes are the byte offsets in the path matched by the
+	// current search query, used for highlighting.
+	MatchedIndexes []int
+	// Detail is the test or module line matched by a content search (#),
+	// shown under the path with its line number.
+	Detail        string
+	DetailLine    int
+	DetailIndexes []int
 }
 
 func (i Item) FilterValue() string {
@@ -42,18 +61,32 @@ func RenderItem(item Item, index int, cursor int, width int, frame int, animate
 	}
 
 	path := item.TestFile.Path
+	offset := 0
 
 	failureMarker := failedMarkerStyle.Render(" ")
 	if item.Failed {
 		failureMarker = failedMarkerStyle.Render("✗")
 	}
 
-	maxPathWidth := width - 10
+	pinMarker := ""
+	if item.Pinned {
+		pinMarker = " " + pinnedMarkerStyle.Render("📌")
+	}
+
+	maxPathWidth := width - 10 - lipgloss.Width(pinMarker)
 	if maxPathWidth > 0 && len(path) > maxPathWidth {
-		path = "..." + path[len(path)-maxPathWidth+3:]
+		offset = len(path) - maxPathWidth + 3
+		path = path[offset:]
 	}
 
-	line := cursorIndicator + " " + checkbox + " " + failureMarker + " " + path
+	line := cursorIndicator + " " + checkbox + " " + failureMarker + " "
+	if offset > 0 {
+		line += "..."
+	}
+	line += highlightMatches(path, item.MatchedIndexes, offset, isCursor) + pinMarker
+	if item.Detail != "" {
+		line += "\n" + renderDetail(item, width, isCursor)
+	}
 
 	if isCursor {
 		return selectedItemStyle.Width(width).Render(line)
@@ -61,3 +94,73 @@ func RenderItem(item Item, index int, cursor int, width int, frame int, animate
 		return itemStyle.Width(width).Render(line)
 	}
 }
+
+// renderDetail renders the second row of a content match, cutting long
+// test names at the end so the line number stays visible.
+func renderDetail(item Item, width int, isCursor bool) string {
+	detail := item.Detail
+	positions := item.DetailIndexes
+	suffix := fmt.Sprintf(" :%d", item.DetailLine)
+	maxDetailWidth := width - 10 - len(suffix)
+	truncated := false
+	if maxDetailWidth > 3 && runewidth.StringWidth(detail) > maxDetailWidth {
+		detail = runewidth.Truncate(detail, maxDetailWidth-3, "")
+		truncated = true
+		positions = clipPositions(positions, len(detail))
+	}
+
+	line := "        ↳ " + highlightMatches(detail, positions, 0, isCursor)
+	if truncated {
+		line += "..."
+	}
+	return line + suffix
+}
+
+// clipPositions drops the byte offsets at or past n.
+func clipPositions(positions []int, n int) []int {
+	clipped := make([]int, 0, len(positions))
+	for _, p := range positions {
+		if p < n {
+			clipped = append(clipped, p)
+		}
+	}
+	return clipped
+}
+
+// highlightMatches renders the characters of text at the given byte
+// positions (relative to the untruncated path, hence offset) in the match
+// style.
+func highlightMatches(text string, positions []int, offset int, isCursor bool) string {
+	if len(positions) == 0 {
+		return text
+	}
+
+	style := matchHighlightStyle
+	if isCursor {
+		style = style.Copy().Background(selectedBg)
+	}
+
+	matched := make(map[int]struct{}, len(positions))
+	for _, p := range positions {
+		matched[p-offset] = struct{}{}
+	}
+
+	var b strings.Builder
+	var run strings.Builder
+	flush := func() {
+		if run.Len() > 0 {
+			b.WriteString(style.Render(run.String()))
+			run.Reset()
+		}
+	}
+	for i, r := range text {
+		if _, ok := matched[i]; ok {
+			run.WriteRune(r)
+			continue
+		}
+		flush()
+		b.WriteRune(r)
+	}
+	flush()
+	return b.String()
+}
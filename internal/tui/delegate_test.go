@@ -3,7 +3,10 @@ package tui
 import (
 	"strings"
 	"testing"
+	"unicode/utf8"
 
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
 	"github.com/samrobinsonsauce/eztest/internal/testfile"
 )
 
@@ -36,3 +39,69 @@ func TestRenderItemHidesFailedMarkerForPassingFile(t *testing.T) {
 		t.Fatalf("did not expect failed marker in rendered item, got %q", rendered)
 	}
 }
+
+func TestRenderItemHighlightsMatchedCharacters(t *testing.T) {
+	lipgloss.SetColorProfile(termenv.ANSI256)
+	t.Cleanup(func() { lipgloss.SetColorProfile(termenv.Ascii) })
+	ApplyTheme("default")
+
+	item := Item{
+		TestFile:       testfile.TestFile{Path: "test/user_test.exs"},
+		MatchedIndexes: []int{5, 6, 7, 8},
+	}
+
+	plain := RenderItem(Item{TestFile: item.TestFile}, 0, 1, 80, 0, false)
+	highlighted := RenderItem(item, 0, 1, 80, 0, false)
+	if highlighted == plain {
+		t.Fatal("expected matched characters to be styled differently")
+	}
+	if want := matchHighlightStyle.Render("user"); !strings.Contains(highlighted, want) {
+		t.Fatalf("expected %q in rendered item, got %q", want, highlighted)
+	}
+}
+
+func TestHighlightMatchesAccountsForTruncation(t *testing.T) {
+	ApplyTheme("default")
+
+	// With no color profile the text must come through unchanged.
+	got := highlightMatches("user_test.exs", []int{5, 6}, 5, false)
+	if got != "user_test.exs" {
+		t.Fatalf("expected text to be preserved, got %q", got)
+	}
+}
+
+func TestRenderItemShowsContentDetail(t *testing.T) {
+	item := Item{
+		TestFile:   testfile.TestFile{Path: "test/auth_test.exs"},
+		Detail:     "verify/1 rejects an expired token",
+		DetailLine: 12,
+	}
+	out := RenderItem(item, 0, 1, 80, 0, false)
+
+	lines := strings.Split(out, "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected a path row and a detail row, got %q", out)
+	}
+	if !strings.Contains(lines[1], "↳ verify/1 rejects an expired token :12") {
+		t.Fatalf("unexpected detail row %q", lines[1])
+	}
+}
+
+func TestRenderDetailTruncatesWideTextByWidth(t *testing.T) {
+	item := Item{
+		TestFile:      testfile.TestFile{Path: "test/i18n_test.exs"},
+		Detail:        "renders 日本語のタイトルとサブタイトル correctly",
+		DetailLine:    7,
+		DetailIndexes: []int{8, 50},
+	}
+	line := renderDetail(item, 40, false)
+	if !utf8.ValidString(line) {
+		t.Fatalf("expected truncation on a rune boundary, got %q", line)
+	}
+	if !strings.HasSuffix(line, "... :7") {
+		t.Fatalf("expected a truncated detail with its line number, got %q", line)
+	}
+	if width := lipgloss.Width(line); width > 40 {
+		t.Fatalf("expected the detail to fit in 40 cells, got %d: %q", width, line)
+	}
+}
@@ -0,0 +1,180 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var elixirKeywords = map[string]struct{}{
+	"after": {}, "alias": {}, "and": {}, "assert": {}, "assert_raise": {}, "assert_receive": {},
+	"case": {}, "catch": {}, "cond": {}, "def": {}, "defdelegate": {}, "defimpl": {},
+	"defmacro": {}, "defmacrop": {}, "defmodule": {}, "defp": {}, "defprotocol": {},
+	"defstruct": {}, "describe": {}, "do": {}, "else": {}, "end": {}, "false": {}, "fn": {},
+	"for": {}, "if": {}, "import": {}, "in": {}, "nil": {}, "not": {}, "or": {}, "quote": {},
+	"raise": {}, "receive": {}, "refute": {}, "refute_receive": {}, "require": {},
+	"rescue": {}, "setup": {}, "setup_all": {}, "test": {}, "true": {}, "try": {},
+	"unless": {}, "unquote": {}, "use": {}, "when": {}, "with": {},
+}
+
+// highlightElixir colours Elixir source line by line. It is a lexer, not a
+// parser: it knows comments, strings and heredocs, atoms, module
+// attributes, module names, numbers and keywords, which is enough to make a
+// test file easy to scan.
+func highlightElixir(lines []string) []string {
+	out := make([]string, len(lines))
+	inHeredoc := false
+	for i, line := range lines {
+		out[i], inHeredoc = highlightElixirLine(strings.ReplaceAll(line, "\t", "  "), inHeredoc)
+	}
+	return out
+}
+
+func highlightElixirLine(line string, inHeredoc bool) (string, bool) {
+	var b strings.Builder
+	emit := func(style lipgloss.Style, text string) {
+		if text != "" {
+			b.WriteString(style.Render(text))
+		}
+	}
+
+	i := 0
+	if inHeredoc {
+		end := strings.Index(line, `"""`)
+		if end < 0 {
+			emit(syntaxStringStyle, line)
+			return b.String(), true
+		}
+		i = end + 3
+		emit(syntaxStringStyle, line[:i])
+	}
+
+	plainStart := i
+	flushPlain := func(end int) {
+		emit(syntaxPlainStyle, line[plainStart:end])
+	}
+
+	for i < len(line) {
+		c := line[i]
+		switch {
+		case c == '#':
+			flushPlain(i)
+			emit(syntaxCommentStyle, line[i:])
+			return b.String(), false
+
+		case strings.HasPrefix(line[i:], `"""`):
+			flushPlain(i)
+			if end := strings.Index(line[i+3:], `"""`); end >= 0 {
+				emit(syntaxStringStyle, line[i:i+3+end+3])
+				i += 3 + end + 3
+				plainStart = i
+				continue
+			}
+			emit(syntaxStringStyle, line[i:])
+			return b.String(), true
+
+		case c == '"' || c == '\'':
+			flushPlain(i)
+			end := closingQuote(line, i+1, c)
+			emit(syntaxStringStyle, line[i:end])
+			i, plainStart = end, end
+			continue
+
+		case c == ':' && i+1 < len(line) && isIdentStart(line[i+1]) && (i == 0 || line[i-1] != ':'):
+			flushPlain(i)
+			end := identEnd(line, i+1)
+			emit(syntaxAtomStyle, line[i:end])
+			i, plainStart = end, end
+			continue
+
+		case c == '@' && i+1 < len(line) && isIdentStart(line[i+1]):
+			flushPlain(i)
+			end := identEnd(line, i+1)
+			emit(syntaxAtomStyle, line[i:end])
+			i, plainStart = end, end
+			continue
+
+		case isDigit(c) && (i == 0 || !isIdentChar(line[i-1])):
+			flushPlain(i)
+			end := i
+			for end < len(line) && (isDigit(line[end]) || line[end] == '_' || line[end] == '.') {
+				end++
+			}
+			emit(syntaxNumberStyle, line[i:end])
+			i, plainStart = end, end
+			continue
+
+		case isIdentStart(c) && (i == 0 || !isIdentChar(line[i-1])):
+			end := identEnd(line, i)
+			word := line[i:end]
+			if end+1 < len(line) && line[end] == ':' && line[end+1] == ' ' {
+				end++ // keyword-list key such as `async: true`
+				flushPlain(i)
+				emit(syntaxAtomStyle, line[i:end])
+				i, plainStart = end, end
+				continue
+			}
+			style, ok := identifierStyle(word)
+			if !ok {
+				i = end
+				continue
+			}
+			flushPlain(i)
+			emit(style, line[i:end])
+			i, plainStart = end, end
+			continue
+		}
+		i++
+	}
+	flushPlain(len(line))
+	return b.String(), false
+}
+
+// identifierStyle picks the style of a module name or keyword, or reports
+// false for a plain variable or function name.
+func identifierStyle(word string) (lipgloss.Style, bool) {
+	if word[0] >= 'A' && word[0] <= 'Z' {
+		return syntaxModuleStyle, true
+	}
+	if _, ok := elixirKeywords[word]; ok {
+		return syntaxKeywordStyle, true
+	}
+	return lipgloss.Style{}, false
+}
+
+// closingQuote returns the index just past the quote that closes a string
+// opened before start, or the end of the line.
+func closingQuote(line string, start int, quote byte) int {
+	for i := start; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case quote:
+			return i + 1
+		}
+	}
+	return len(line)
+}
+
+func identEnd(line string, start int) int {
+	end := start
+	for end < len(line) && isIdentChar(line[end]) {
+		end++
+	}
+	if end < len(line) && (line[end] == '?' || line[end] == '!') {
+		end++
+	}
+	return end
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || isDigit(c)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
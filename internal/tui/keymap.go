@@ -2,31 +2,99 @@ package tui
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
 )
 
 const (
-	actionUp          = "up"
-	actionDown        = "down"
-	actionSelect      = "select"
-	actionSelectAll   = "select_all"
-	actionDeselectAll = "deselect_all"
-	actionRun         = "run"
-	actionSaveQuit    = "save_quit"
-	actionQuit        = "quit"
+	actionUp              = "up"
+	actionDown            = "down"
+	actionSelect          = "select"
+	actionSelectAll       = "select_all"
+	actionDeselectAll     = "deselect_all"
+	actionRun             = "run"
+	actionSaveQuit        = "save_quit"
+	actionQuit            = "quit"
+	actionNextSet         = "next_set"
+	actionSaveSet         = "save_set"
+	actionToggleView      = "toggle_view"
+	actionExpand          = "expand"
+	actionCollapse        = "collapse"
+	actionPreview         = "toggle_preview"
+	actionPreviewUp       = "preview_up"
+	actionPreviewDown     = "preview_down"
+	actionOpen            = "open"
+	actionInvert          = "invert"
+	actionDeselectVisible = "deselect_visible"
+	actionMark            = "mark"
+	actionSelectRange     = "select_range"
+	actionSelectGlob      = "select_glob"
+	actionSort            = "sort"
+	actionHistoryPrev     = "history_prev"
+	actionHistoryNext     = "history_next"
+	actionPin             = "pin"
+	actionHide            = "hide"
+	actionUndo            = "undo"
+	actionRedo            = "redo"
+	actionHelp            = "help"
+	actionPalette         = "palette"
 )
 
 type KeyMap struct {
-	Up          key.Binding
-	Down        key.Binding
-	Select      key.Binding
-	SelectAll   key.Binding
-	DeselectAll key.Binding
-	Run         key.Binding
-	SaveQuit    key.Binding
-	Quit        key.Binding
+	Up              key.Binding
+	Down            key.Binding
+	Select          key.Binding
+	SelectAll       key.Binding
+	DeselectAll     key.Binding
+	Run             key.Binding
+	SaveQuit        key.Binding
+	Quit            key.Binding
+	NextSet         key.Binding
+	SaveSet         key.Binding
+	ToggleView      key.Binding
+	Expand          key.Binding
+	Collapse        key.Binding
+	Preview         key.Binding
+	PreviewUp       key.Binding
+	PreviewDown     key.Binding
+	Open            key.Binding
+	Invert          key.Binding
+	DeselectVisible key.Binding
+	Mark            key.Binding
+	SelectRange     key.Binding
+	SelectGlob      key.Binding
+	Sort            key.Binding
+	HistoryPrev     key.Binding
+	HistoryNext     key.Binding
+	Pin             key.Binding
+	Hide            key.Binding
+	Undo            key.Binding
+	Redo            key.Binding
+	Help            key.Binding
+	Palette         key.Binding
+
+	// sequences are the bindings that take several keys in a row. Their
+	// actions' key.Bindings only hold the single keys.
+	sequences []Sequence
+	// custom holds the bindings of the config's custom actions.
+	custom []ActionBinding
+}
+
+// Sequence is a binding made of several keys pressed in a row, such as
+// "g g" or "space r f".
+type Sequence struct {
+	Action string
+	Keys   []string
+}
+
+// ActionBinding pairs a config action name with its resolved binding.
+type ActionBinding struct {
+	Action  string
+	Binding key.Binding
 }
 
 func DefaultKeyMap() KeyMap {
@@ -50,55 +118,337 @@ func NewKeyMap(overrides map[string][]string) KeyMap {
 		bindings[action] = cleaned
 	}
 
+	var sequences []Sequence
+	for _, action := range ActionNames() {
+		for _, k := range bindings[action] {
+			if isSequence(k) {
+				sequences = append(sequences, Sequence{Action: action, Keys: strings.Split(k, " ")})
+			}
+		}
+	}
+
 	return KeyMap{
-		Up:          makeBinding(bindings[actionUp], "up"),
-		Down:        makeBinding(bindings[actionDown], "down"),
-		Select:      makeBinding(bindings[actionSelect], "select"),
-		SelectAll:   makeBinding(bindings[actionSelectAll], "select all"),
-		DeselectAll: makeBinding(bindings[actionDeselectAll], "deselect all"),
-		Run:         makeBinding(bindings[actionRun], "run tests"),
-		SaveQuit:    makeBinding(bindings[actionSaveQuit], "save & quit"),
-		Quit:        makeBinding(bindings[actionQuit], "quit"),
+		sequences:       sequences,
+		Up:              makeBinding(bindings[actionUp], "up"),
+		Down:            makeBinding(bindings[actionDown], "down"),
+		Select:          makeBinding(bindings[actionSelect], "select"),
+		SelectAll:       makeBinding(bindings[actionSelectAll], "select all"),
+		DeselectAll:     makeBinding(bindings[actionDeselectAll], "deselect all"),
+		Run:             makeBinding(bindings[actionRun], "run tests"),
+		SaveQuit:        makeBinding(bindings[actionSaveQuit], "save & quit"),
+		Quit:            makeBinding(bindings[actionQuit], "quit"),
+		NextSet:         makeBinding(bindings[actionNextSet], "next set"),
+		SaveSet:         makeBinding(bindings[actionSaveSet], "save set"),
+		ToggleView:      makeBinding(bindings[actionToggleView], "tree/flat"),
+		Expand:          makeBinding(bindings[actionExpand], "expand"),
+		Collapse:        makeBinding(bindings[actionCollapse], "collapse"),
+		Preview:         makeBinding(bindings[actionPreview], "preview"),
+		PreviewUp:       makeBinding(bindings[actionPreviewUp], "scroll preview up"),
+		PreviewDown:     makeBinding(bindings[actionPreviewDown], "scroll preview down"),
+		Open:            makeBinding(bindings[actionOpen], "open in editor"),
+		Invert:          makeBinding(bindings[actionInvert], "invert visible"),
+		DeselectVisible: makeBinding(bindings[actionDeselectVisible], "deselect visible"),
+		Mark:            makeBinding(bindings[actionMark], "mark range start"),
+		SelectRange:     makeBinding(bindings[actionSelectRange], "select marked range"),
+		SelectGlob:      makeBinding(bindings[actionSelectGlob], "select by glob"),
+		Sort:            makeBinding(bindings[actionSort], "cycle sort"),
+		HistoryPrev:     makeBinding(bindings[actionHistoryPrev], "previous search"),
+		HistoryNext:     makeBinding(bindings[actionHistoryNext], "next search"),
+		Pin:             makeBinding(bindings[actionPin], "pin to top"),
+		Hide:            makeBinding(bindings[actionHide], "hide file"),
+		Undo:            makeBinding(bindings[actionUndo], "undo"),
+		Redo:            makeBinding(bindings[actionRedo], "redo"),
+		Help:            makeBinding(bindings[actionHelp], "help"),
+		Palette:         makeBinding(bindings[actionPalette], "command palette"),
 	}
 }
 
-func (k KeyMap) ShortHelp(compact bool) string {
-	entries := []key.Binding{
-		k.Up,
-		k.Down,
-		k.Select,
-		k.SelectAll,
-		k.DeselectAll,
-		k.Run,
-		k.SaveQuit,
-		k.Quit,
+// Bindings lists every action with its binding, in legend order, followed
+// by the custom actions.
+func (k KeyMap) Bindings() []ActionBinding {
+	return append([]ActionBinding{
+		{actionUp, k.Up},
+		{actionDown, k.Down},
+		{actionSelect, k.Select},
+		{actionSelectAll, k.SelectAll},
+		{actionDeselectAll, k.DeselectAll},
+		{actionRun, k.Run},
+		{actionSaveQuit, k.SaveQuit},
+		{actionQuit, k.Quit},
+		{actionNextSet, k.NextSet},
+		{actionSaveSet, k.SaveSet},
+		{actionToggleView, k.ToggleView},
+		{actionExpand, k.Expand},
+		{actionCollapse, k.Collapse},
+		{actionPreview, k.Preview},
+		{actionPreviewUp, k.PreviewUp},
+		{actionPreviewDown, k.PreviewDown},
+		{actionOpen, k.Open},
+		{actionInvert, k.Invert},
+		{actionDeselectVisible, k.DeselectVisible},
+		{actionMark, k.Mark},
+		{actionSelectRange, k.SelectRange},
+		{actionSelectGlob, k.SelectGlob},
+		{actionSort, k.Sort},
+		{actionHistoryPrev, k.HistoryPrev},
+		{actionHistoryNext, k.HistoryNext},
+		{actionPin, k.Pin},
+		{actionHide, k.Hide},
+		{actionUndo, k.Undo},
+		{actionRedo, k.Redo},
+		{actionHelp, k.Help},
+		{actionPalette, k.Palette},
+	}, k.custom...)
+}
+
+// actionGroups arranges every action by category for the help overlay.
+var actionGroups = []struct {
+	Title   string
+	Actions []string
+}{
+	{"Navigation", []string{actionUp, actionDown, actionToggleView, actionExpand, actionCollapse, actionSort, actionPin, actionHide}},
+	{"Search", []string{actionHistoryPrev, actionHistoryNext}},
+	{"Selection", []string{actionSelect, actionSelectAll, actionDeselectAll, actionInvert, actionDeselectVisible, actionMark, actionSelectRange, actionSelectGlob, actionUndo, actionRedo}},
+	{"Named sets", []string{actionNextSet, actionSaveSet}},
+	{"Preview", []string{actionPreview, actionPreviewUp, actionPreviewDown, actionOpen}},
+	{"Running", []string{actionRun, actionSaveQuit, actionQuit}},
+	{"Help", []string{actionHelp, actionPalette}},
+}
+
+// binding returns the binding of an action.
+func (k KeyMap) binding(action string) key.Binding {
+	for _, ab := range k.Bindings() {
+		if ab.Action == action {
+			return ab.Binding
+		}
 	}
-	if compact {
-		entries = []key.Binding{k.Up, k.Down, k.Select, k.Run, k.Quit}
+	return key.Binding{}
+}
+
+// Action returns the action bound to a single keypress, or "".
+func (k KeyMap) Action(msg tea.KeyMsg) string {
+	for _, ab := range k.Bindings() {
+		if key.Matches(msg, ab.Binding) {
+			return ab.Action
+		}
 	}
+	return ""
+}
 
-	parts := make([]string, 0, len(entries))
-	for _, binding := range entries {
-		help := binding.Help()
-		if help.Key == "" || help.Desc == "" {
+// Sequences returns the multi-key bindings.
+func (k KeyMap) Sequences() []Sequence {
+	return k.sequences
+}
+
+// matchSequence returns the action whose sequence is exactly keys, and
+// whether keys are the start of a longer sequence.
+func (k KeyMap) matchSequence(keys []string, typing bool) (string, bool) {
+	prefix := false
+	for _, seq := range k.sequences {
+		if typing && isPrintableKey(seq.Keys[0]) {
 			continue
 		}
-		parts = append(parts, fmt.Sprintf("%s: %s", help.Key, help.Desc))
+		if len(seq.Keys) < len(keys) || !equalKeys(seq.Keys[:len(keys)], keys) {
+			continue
+		}
+		if len(seq.Keys) == len(keys) {
+			return seq.Action, false
+		}
+		prefix = true
 	}
+	return "", prefix
+}
 
+// sequenceHelp describes the sequences that can complete the pending keys,
+// for example "r f: run tests".
+func (k KeyMap) sequenceHelp(pending []string) string {
+	var parts []string
+	for _, seq := range k.sequences {
+		if len(seq.Keys) <= len(pending) || !equalKeys(seq.Keys[:len(pending)], pending) {
+			continue
+		}
+		desc := k.binding(seq.Action).Help().Desc
+		parts = append(parts, fmt.Sprintf("%s: %s", formatHelpKey(strings.Join(seq.Keys[len(pending):], " ")), desc))
+	}
 	return strings.Join(parts, " • ")
 }
 
+func equalKeys(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// isSequence reports whether a normalized key name is a sequence. Its keys
+// are separated by single spaces, with the space key itself written "space".
+func isSequence(name string) bool {
+	return name != " " && strings.Contains(name, " ")
+}
+
+// isPrintableKey reports whether a key types a character. Sequences that
+// start with one only apply in normal mode, so they never swallow a search.
+func isPrintableKey(name string) bool {
+	return name == "space" || utf8.RuneCountInString(name) == 1
+}
+
+// sequenceKeyName is the name a keypress has inside a sequence.
+func sequenceKeyName(msg tea.KeyMsg) string {
+	if name := msg.String(); name != " " {
+		return name
+	}
+	return "space"
+}
+
+// ActionNames returns every action that can be configured under "keybinds".
+func ActionNames() []string {
+	bindings := defaultBindings()
+	names := make([]string, 0, len(bindings))
+	for name := range bindings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DefaultBindings returns the default keys for every action.
+func DefaultBindings() map[string][]string {
+	return defaultBindings()
+}
+
+// NormalizeKeyName converts a configured key name into the form Bubble Tea
+// reports, for example "Ctrl-K" to "ctrl+k" and "space" to " ". A name with
+// several keys separated by spaces, such as "g g", is a sequence.
+func NormalizeKeyName(name string) string {
+	return normalizeBindingKey(name)
+}
+
+var namedKeys = func() map[string]struct{} {
+	names := map[string]struct{}{}
+	for k := -128; k <= 127; k++ {
+		name := tea.KeyType(k).String()
+		if name == "" || name == "runes" || strings.HasPrefix(name, "\x1b") {
+			continue
+		}
+		names[name] = struct{}{}
+	}
+	return names
+}()
+
+// IsValidKeyName reports whether a normalized key name can ever be produced
+// by a keypress: a named key, a single character, or either with alt+. Each
+// key of a sequence must be valid.
+func IsValidKeyName(name string) bool {
+	if isSequence(name) {
+		for _, part := range strings.Split(name, " ") {
+			if part == "space" {
+				part = " "
+			}
+			if part == "" || isSequence(part) || !IsValidKeyName(part) {
+				return false
+			}
+		}
+		return true
+	}
+	if _, ok := namedKeys[name]; ok {
+		return true
+	}
+	if utf8.RuneCountInString(name) == 1 {
+		return true
+	}
+	if rest := strings.TrimPrefix(name, "alt+"); rest != name && rest != "" {
+		return IsValidKeyName(rest)
+	}
+	return false
+}
+
+func (k KeyMap) ShortHelp(compact bool) string {
+	entries := k.shortHelpBindings(compact)
+	parts := make([]string, 0, len(entries))
+	for _, ab := range entries {
+		parts = append(parts, helpEntry(ab.Binding))
+	}
+	return strings.Join(parts, helpSeparator)
+}
+
+const helpSeparator = " • "
+
+// shortHelpBindings lists the actions in the help line that have a key and
+// a description, in order.
+func (k KeyMap) shortHelpBindings(compact bool) []ActionBinding {
+	entries := []ActionBinding{
+		{actionUp, k.Up},
+		{actionDown, k.Down},
+		{actionSelect, k.Select},
+		{actionSelectAll, k.SelectAll},
+		{actionDeselectAll, k.DeselectAll},
+		{actionRun, k.Run},
+		{actionSaveQuit, k.SaveQuit},
+		{actionQuit, k.Quit},
+		{actionNextSet, k.NextSet},
+		{actionSaveSet, k.SaveSet},
+		{actionToggleView, k.ToggleView},
+		{actionPreview, k.Preview},
+		{actionOpen, k.Open},
+		{actionUndo, k.Undo},
+		{actionHelp, k.Help},
+	}
+	if compact {
+		entries = []ActionBinding{{actionUp, k.Up}, {actionDown, k.Down}, {actionSelect, k.Select}, {actionRun, k.Run}, {actionQuit, k.Quit}, {actionHelp, k.Help}}
+	}
+
+	shown := entries[:0]
+	for _, ab := range entries {
+		if help := ab.Binding.Help(); help.Key != "" && help.Desc != "" {
+			shown = append(shown, ab)
+		}
+	}
+	return shown
+}
+
+func helpEntry(binding key.Binding) string {
+	help := binding.Help()
+	return fmt.Sprintf("%s: %s", help.Key, help.Desc)
+}
+
 func defaultBindings() map[string][]string {
 	return map[string][]string{
-		actionUp:          []string{"up", "ctrl+k"},
-		actionDown:        []string{"down", "ctrl+j"},
-		actionSelect:      []string{"tab"},
-		actionSelectAll:   []string{"ctrl+a"},
-		actionDeselectAll: []string{"ctrl+d"},
-		actionRun:         []string{"enter"},
-		actionSaveQuit:    []string{"ctrl+s"},
-		actionQuit:        []string{"ctrl+c", "esc"},
+		actionUp:              []string{"up", "ctrl+k"},
+		actionDown:            []string{"down", "ctrl+j"},
+		actionSelect:          []string{"tab"},
+		actionSelectAll:       []string{"ctrl+a"},
+		actionDeselectAll:     []string{"ctrl+d"},
+		actionRun:             []string{"enter"},
+		actionSaveQuit:        []string{"ctrl+s"},
+		actionQuit:            []string{"ctrl+c", "esc"},
+		actionNextSet:         []string{"ctrl+n"},
+		actionSaveSet:         []string{"alt+w"},
+		actionToggleView:      []string{"ctrl+t"},
+		actionExpand:          []string{"alt+right", "alt+l"},
+		actionCollapse:        []string{"alt+left", "alt+h"},
+		actionPreview:         []string{"alt+v"},
+		actionPreviewUp:       []string{"shift+up", "pgup"},
+		actionPreviewDown:     []string{"shift+down", "pgdown"},
+		actionOpen:            []string{"ctrl+o"},
+		actionInvert:          []string{"alt+i"},
+		actionDeselectVisible: []string{"alt+d"},
+		actionMark:            []string{"alt+m"},
+		actionSelectRange:     []string{"alt+r"},
+		actionSelectGlob:      []string{"alt+g"},
+		actionSort:            []string{"alt+s"},
+		actionHistoryPrev:     []string{"alt+p"},
+		actionHistoryNext:     []string{"alt+n"},
+		actionPin:             []string{"alt+f"},
+		actionHide:            []string{"alt+x"},
+		actionUndo:            []string{"ctrl+z"},
+		actionRedo:            []string{"ctrl+y"},
+		actionHelp:            []string{"?", "f1"},
+		actionPalette:         []string{"ctrl+p"},
 	}
 }
 
@@ -120,6 +470,15 @@ func cleanBindingKeys(keys []string) []string {
 }
 
 func normalizeBindingKey(key string) string {
+	if parts := strings.Fields(key); len(parts) > 1 {
+		for i, part := range parts {
+			if parts[i] = normalizeBindingKey(part); parts[i] == " " {
+				parts[i] = "space"
+			}
+		}
+		return strings.Join(parts, " ")
+	}
+
 	key = strings.ToLower(strings.TrimSpace(key))
 	switch key {
 	case "":
@@ -147,8 +506,14 @@ func normalizeBindingKey(key string) string {
 }
 
 func makeBinding(keys []string, helpDescription string) key.Binding {
+	single := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if !isSequence(k) {
+			single = append(single, k)
+		}
+	}
 	return key.NewBinding(
-		key.WithKeys(keys...),
+		key.WithKeys(single...),
 		key.WithHelp(formatHelpKeys(keys), helpDescription),
 	)
 }
@@ -166,6 +531,14 @@ func formatHelpKeys(keys []string) string {
 }
 
 func formatHelpKey(keyName string) string {
+	if isSequence(keyName) {
+		parts := strings.Split(keyName, " ")
+		for i, part := range parts {
+			parts[i] = formatHelpKey(part)
+		}
+		return strings.Join(parts, " ")
+	}
+
 	switch keyName {
 	case "up":
 		return "↑"
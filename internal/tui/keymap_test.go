@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 
@@ -81,3 +82,87 @@ func TestNewKeyMapSpaceAliasMatchesKeypress(t *testing.T) {
 		t.Fatalf("expected help label to display as space, got %q", keyLabel)
 	}
 }
+
+func TestIsValidKeyName(t *testing.T) {
+	valid := []string{"ctrl+a", "enter", " ", "k", "?", "alt+w", "alt+up", "shift+tab", "f5", "pgdown"}
+	for _, k := range valid {
+		if !IsValidKeyName(k) {
+			t.Errorf("expected %q to be a valid key", k)
+		}
+	}
+
+	invalid := []string{"ctrl+shift+q", "hyper+x", "alt+", "enterr", "ctrl+1"}
+	for _, k := range invalid {
+		if IsValidKeyName(k) {
+			t.Errorf("expected %q to be rejected", k)
+		}
+	}
+}
+
+func TestActionNamesCoverKeyMapBindings(t *testing.T) {
+	names := map[string]bool{}
+	for _, name := range ActionNames() {
+		names[name] = true
+	}
+	for _, ab := range DefaultKeyMap().Bindings() {
+		if !names[ab.Action] {
+			t.Errorf("binding %q is missing from ActionNames", ab.Action)
+		}
+	}
+	if len(names) != len(DefaultKeyMap().Bindings()) {
+		t.Errorf("ActionNames has %d entries, key map has %d bindings", len(names), len(DefaultKeyMap().Bindings()))
+	}
+}
+
+func TestNewKeyMapParsesSequences(t *testing.T) {
+	keyMap := NewKeyMap(map[string][]string{
+		"run":        []string{"enter", "Space  R F"},
+		"select_all": []string{"g a"},
+	})
+
+	if got := keyMap.Run.Keys(); len(got) != 1 || got[0] != "enter" {
+		t.Fatalf("expected only single keys on the binding, got %v", got)
+	}
+	want := []Sequence{
+		{Action: "run", Keys: []string{"space", "r", "f"}},
+		{Action: "select_all", Keys: []string{"g", "a"}},
+	}
+	if got := keyMap.Sequences(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got sequences %v want %v", got, want)
+	}
+	if keyLabel := keyMap.Run.Help().Key; keyLabel != "enter/space r f" {
+		t.Fatalf("expected the legend to show the sequence, got %q", keyLabel)
+	}
+
+	for _, k := range []string{"g g", "space r f", "ctrl+x alt+up"} {
+		if !IsValidKeyName(k) {
+			t.Errorf("expected sequence %q to be valid", k)
+		}
+	}
+	if IsValidKeyName("g hyper+x") {
+		t.Error("expected a sequence with an unknown key to be rejected")
+	}
+}
+
+func TestActionGroupsCoverEveryAction(t *testing.T) {
+	seen := map[string]int{}
+	for _, group := range actionGroups {
+		for _, action := range group.Actions {
+			seen[action]++
+		}
+	}
+	for _, name := range ActionNames() {
+		if seen[name] != 1 {
+			t.Errorf("action %q appears %d times in the help groups", name, seen[name])
+		}
+	}
+}
+
+func TestHelpTextListsConfiguredKeys(t *testing.T) {
+	text := NewKeyMap(map[string][]string{"pin": {"alt+b"}}).HelpText("  ")
+	for _, want := range []string{"  Selection:\n", "alt+b", "pin to top", "Normal mode:"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected help text to contain %q:\n%s", want, text)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package tui
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type inputMode int
+
+const (
+	modeInsert inputMode = iota
+	modeNormal
+)
+
+// normalHelp is the legend shown in normal mode, ahead of the key map's.
+const normalHelp = "j/k: move • gg/G: top/bottom • x: toggle • V: visual • n/N: failures • u: undo • /: search • i: insert"
+
+// WithModal enables vim-style modes. The model starts in normal mode, where
+// keys navigate instead of editing the filter.
+func (m Model) WithModal(enabled bool) Model {
+	m.modal = enabled
+	if enabled {
+		m.enterNormal()
+	}
+	return m
+}
+
+func (m *Model) enterNormal() {
+	m.mode = modeNormal
+	m.pendingG = false
+	m.searchInput.Blur()
+}
+
+func (m *Model) enterInsert() tea.Cmd {
+	m.mode = modeInsert
+	m.visual = false
+	m.pendingG = false
+	m.searchInput.Focus()
+	return textinput.Blink
+}
+
+// modeLabel is shown at the start of the status line in modal mode.
+func (m Model) modeLabel() string {
+	switch {
+	case !m.modal:
+		return ""
+	case m.visual:
+		return "-- VISUAL --"
+	case m.mode == modeNormal:
+		return "-- NORMAL --"
+	}
+	return "-- INSERT --"
+}
+
+// updateNormal handles a key in normal mode. Keys it does not handle fall
+// through to the key map, but never reach the search input.
+func (m *Model) updateNormal(msg tea.KeyMsg) (bool, tea.Cmd) {
+	pendingG := m.pendingG
+	m.pendingG = false
+
+	switch msg.String() {
+	case "j", "down":
+		m.moveCursor(1)
+	case "k", "up":
+		m.moveCursor(-1)
+	case "ctrl+d":
+		m.moveCursor(m.halfPage())
+	case "ctrl+u":
+		m.moveCursor(-m.halfPage())
+	case "g":
+		if pendingG {
+			m.cursor = 0
+		} else {
+			m.pendingG = true
+		}
+	case "G":
+		m.cursor = max(m.rowCount()-1, 0)
+	case "x", " ":
+		m.toggleNormalSelection()
+	case "u":
+		m.undo()
+	case "ctrl+r":
+		m.redo()
+	case "V":
+		m.visual = !m.visual
+		m.visualAnchor = m.cursor
+	case "n":
+		m.jumpToFailure(1)
+	case "N":
+		m.jumpToFailure(-1)
+	case "/":
+		m.searchInput.SetValue("")
+		m.updateFilter()
+		return true, m.enterInsert()
+	case "i", "a":
+		return true, m.enterInsert()
+	case "esc":
+		// Esc only leaves visual mode or drops a pending g; it never quits
+		// from normal mode.
+		m.visual = false
+	default:
+		if key.Matches(msg, m.keyMap.Select) {
+			m.toggleNormalSelection()
+			return true, nil
+		}
+		return false, nil
+	}
+	return true, nil
+}
+
+func (m *Model) moveCursor(delta int) {
+	m.cursor = min(max(m.cursor+delta, 0), max(m.rowCount()-1, 0))
+}
+
+// visualRange returns the rows between the visual anchor and the cursor.
+func (m Model) visualRange() (int, int) {
+	start, end := m.visualAnchor, m.cursor
+	if start > end {
+		start, end = end, start
+	}
+	return start, min(end, m.rowCount()-1)
+}
+
+func (m Model) inVisualRange(row int) bool {
+	if !m.visual {
+		return false
+	}
+	start, end := m.visualRange()
+	return row >= start && row <= end
+}
+
+// toggleNormalSelection toggles the cursor row, or in visual mode selects
+// every file in the range (deselecting them if all already are) and leaves
+// visual mode.
+func (m *Model) toggleNormalSelection() {
+	if !m.visual {
+		m.changeSelection("toggle", m.toggleCursorSelection)
+		return
+	}
+	m.changeSelection("range selection", m.toggleVisualRange)
+}
+
+func (m *Model) toggleVisualRange() {
+	start, end := m.visualRange()
+	var items []int
+	for row := start; row <= end; row++ {
+		items = append(items, m.itemsOnRow(row)...)
+	}
+	allSelected := true
+	for _, i := range items {
+		allSelected = allSelected && m.filteredItems[i].Selected
+	}
+	for _, i := range items {
+		m.setItemSelected(i, !allSelected)
+	}
+	m.visual = false
+}
+
+// itemsOnRow returns the filteredItems on a row: the file, or every file
+// below a tree directory.
+func (m Model) itemsOnRow(row int) []int {
+	if m.treeView {
+		if m.treeRows[row].isDir() {
+			return m.treeRows[row].items
+		}
+		return []int{m.treeRows[row].item}
+	}
+	return []int{row}
+}
+
+// jumpToFailure moves the cursor to the next (dir 1) or previous (dir -1)
+// failed file, wrapping around.
+func (m *Model) jumpToFailure(dir int) {
+	n := m.rowCount()
+	for step := 1; step <= n; step++ {
+		row := ((m.cursor+dir*step)%n + n) % n
+		if item := m.rowItem(row); item >= 0 && m.filteredItems[item].Failed {
+			m.cursor = row
+			return
+		}
+	}
+	m.notice = "No failed files in the list"
+}
@@ -0,0 +1,119 @@
+package tui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func testModelForModal(t *testing.T) Model {
+	return newTestModel(t,
+		[]string{"test/a_test.exs", "test/b_test.exs", "test/c_test.exs", "test/d_test.exs"},
+		withFailed("test/b_test.exs", "test/d_test.exs")).
+		WithModal(true)
+}
+
+func pressKeys(m Model, keys ...string) Model {
+	for _, k := range keys {
+		var msg tea.KeyMsg
+		switch k {
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		case "ctrl+d":
+			msg = tea.KeyMsg{Type: tea.KeyCtrlD}
+		case " ":
+			msg = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		}
+		updated, _ := m.Update(msg)
+		m = updated.(Model)
+	}
+	return m
+}
+
+func TestNormalModeNavigation(t *testing.T) {
+	m := testModelForModal(t)
+	if !strings.Contains(m.View(), "-- NORMAL --") {
+		t.Fatal("expected the status line to show normal mode")
+	}
+
+	steps := []struct {
+		keys   []string
+		cursor int
+	}{
+		{[]string{"j", "j"}, 2},
+		{[]string{"k"}, 1},
+		{[]string{"G"}, 3},
+		{[]string{"g", "g"}, 0},
+		{[]string{"n"}, 1},
+		{[]string{"n"}, 3},
+		{[]string{"n"}, 1},
+		{[]string{"N"}, 3},
+		{[]string{"g", "g", "ctrl+d"}, 3},
+	}
+	for _, step := range steps {
+		m = pressKeys(m, step.keys...)
+		if m.cursor != step.cursor {
+			t.Fatalf("after %v expected cursor %d, got %d", step.keys, step.cursor, m.cursor)
+		}
+	}
+	if got := m.searchInput.Value(); got != "" {
+		t.Fatalf("normal mode keys must not reach the filter, got %q", got)
+	}
+}
+
+func TestNormalModeSelectionAndVisualRange(t *testing.T) {
+	m := testModelForModal(t)
+
+	m = pressKeys(m, "x")
+	if got := m.getSelectedFiles(); !reflect.DeepEqual(got, []string{"test/a_test.exs"}) {
+		t.Fatalf("expected x to toggle the cursor file, got %v", got)
+	}
+
+	m = pressKeys(m, "j", "V", "j", "j")
+	if !m.visual || !strings.Contains(m.View(), "-- VISUAL --") {
+		t.Fatal("expected visual mode")
+	}
+	m = pressKeys(m, " ")
+	want := []string{"test/a_test.exs", "test/b_test.exs", "test/c_test.exs", "test/d_test.exs"}
+	if got := m.getSelectedFiles(); !reflect.DeepEqual(got, want) || m.visual {
+		t.Fatalf("expected the range to be selected and visual mode to end, got %v (visual %v)", got, m.visual)
+	}
+
+	m = pressKeys(m, "V", "k", "k", "x")
+	if got := m.getSelectedFiles(); !reflect.DeepEqual(got, []string{"test/a_test.exs"}) {
+		t.Fatalf("expected a fully selected range to be deselected, got %v", got)
+	}
+}
+
+func TestModalSearchAndEscape(t *testing.T) {
+	m := testModelForModal(t)
+
+	m = pressKeys(m, "/", "c", "_")
+	if m.mode != modeInsert || m.searchInput.Value() != "c_" {
+		t.Fatalf("expected / to start a search, got mode %v value %q", m.mode, m.searchInput.Value())
+	}
+	if len(m.filteredItems) != 1 {
+		t.Fatalf("expected the filter to apply, got %d items", len(m.filteredItems))
+	}
+
+	m = pressKeys(m, "esc")
+	if m.mode != modeNormal || m.IsQuitting() {
+		t.Fatal("expected esc in insert mode to return to normal mode")
+	}
+	m = pressKeys(m, "j")
+	if m.searchInput.Value() != "c_" {
+		t.Fatalf("expected the filter to be kept, got %q", m.searchInput.Value())
+	}
+
+	m = pressKeys(m, "G", "g", "esc", "g", "esc")
+	if m.IsQuitting() || m.mode != modeNormal || m.pendingG {
+		t.Fatal("expected esc in normal mode to drop the pending g and not quit")
+	}
+	if m.cursor != max(m.rowCount()-1, 0) {
+		t.Fatalf("expected esc to cancel the pending g, got cursor %d", m.cursor)
+	}
+}
@@ -2,14 +2,14 @@ package tui
 
 import (
 	"fmt"
-	"sort"
 	"strings"
 	"time"
 
-	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/samrobinsonsauce/eztest/internal/config"
+	"github.com/samrobinsonsauce/eztest/internal/search"
 	"github.com/samrobinsonsauce/eztest/internal/testfile"
 )
 
@@ -27,10 +27,98 @@ type Model struct {
 	frame         int
 	filesToRun    []string
 	quitting      bool
+
+	sets          []NamedSet
+	setIndex      int
+	baseSelection []string
+
+	prompt     textinput.Model
+	promptKind promptKind
+	notice     string
+
+	// queryErr explains why the current search query could not be parsed.
+	// The previous results stay visible until it is fixed.
+	queryErr string
+
+	// contentIndex holds each file's outline for content search (#). It is
+	// built in the background; indexing is true until it arrives.
+	contentIndex     testfile.ContentIndex
+	contentCachePath string
+	indexing         bool
+
+	// treeView groups the list by directory. cursor then indexes treeRows.
+	treeView  bool
+	collapsed map[string]bool
+	treeRows  []treeRow
+
+	// The preview pane shows the file under the cursor. previewScroll
+	// applies while the cursor stays on previewPath.
+	previewMode     previewMode
+	previewPath     string
+	previewScroll   int
+	previews        map[string]*filePreview
+	failureMessages map[string]string
+
+	// listOffset is the first list row shown after the wheel scrolled the
+	// list. It applies while listScrolled is set; the next key clears it so
+	// the list follows the cursor again.
+	listOffset   int
+	listScrolled bool
+
+	// customActions are the config's custom actions by name.
+	customActions map[string]CustomAction
+
+	// openCommand is the open.command template; empty uses $EDITOR.
+	openCommand string
+
+	// In modal mode keys navigate in normal mode and edit the filter in
+	// insert mode. visual marks the rows from visualAnchor to the cursor.
+	modal        bool
+	mode         inputMode
+	pendingG     bool
+	visual       bool
+	visualAnchor int
+
+	// pendingKeys are the keys of a sequence typed so far. sequenceID tells
+	// the current sequence's timeout from stale ones; replaying is set while
+	// an abandoned sequence's keys are handled alone.
+	pendingKeys []tea.KeyMsg
+	sequenceID  int
+	replaying   bool
+
+	// overlay covers the list with the help screen or the command palette.
+	overlay        overlayKind
+	overlayScroll  int
+	palette        textinput.Model
+	paletteCursor  int
+	paletteMatches []paletteEntry
+
+	// undoStack and redoStack hold selection changes, most recent last.
+	undoStack []selectionChange
+	redoStack []selectionChange
+
+	// mark is the path of the file marked as one end of a range.
+	mark string
+
+	// sortMode orders allItems, and breaks ties between equally relevant
+	// search results. The maps hold what the modes sort by.
+	sortMode  string
+	mtimes    map[string]time.Time
+	durations map[string]time.Duration
+	frecency  map[string]float64
+
+	// searchHistory holds past queries, oldest first. historyIndex is the
+	// entry shown in the search box, len(searchHistory) while typing a new
+	// query, and historyDraft what was typed before recalling.
+	searchHistory []string
+	historyIndex  int
+	historyDraft  string
 }
 
 type tickMsg time.Time
 
+type contentIndexMsg testfile.ContentIndex
+
 func NewModel(testFiles []testfile.TestFile, projectDir string, selections []string, failures []string, keyMap KeyMap, ui config.UISettings) Model {
 	selectedSet := make(map[string]bool)
 	for _, s := range selections {
@@ -59,7 +147,7 @@ func NewModel(testFiles []testfile.TestFile, projectDir string, selections []str
 	ti.TextStyle = searchInputStyle
 	ti.Prompt = "🔍 "
 
-	if len(keyMap.Up.Keys()) == 0 {
+	if keyMap.Up.Help().Desc == "" {
 		keyMap = DefaultKeyMap()
 	}
 
@@ -75,6 +163,58 @@ func NewModel(testFiles []testfile.TestFile, projectDir string, selections []str
 		width:         80,
 		height:        24,
 		frame:         0,
+		setIndex:      -1,
+		collapsed:     map[string]bool{},
+		previews:      map[string]*filePreview{},
+	}
+}
+
+// WithChanged marks the given files as touched by uncommitted changes so
+// the @changed filter can find them.
+func (m Model) WithChanged(paths []string) Model {
+	changed := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		changed[p] = true
+	}
+
+	items := make([]Item, len(m.allItems))
+	for i, item := range m.allItems {
+		item.Changed = changed[item.TestFile.Path]
+		items[i] = item
+	}
+	m.allItems = items
+	m.updateFilter()
+	return m
+}
+
+// WithView restores the saved list layout: tree or flat, and which tree
+// directories are collapsed.
+func (m Model) WithView(tree bool, collapsed []string) Model {
+	m.treeView = tree
+	m.collapsed = make(map[string]bool, len(collapsed))
+	for _, dir := range collapsed {
+		m.collapsed[dir] = true
+	}
+	m.refreshTree()
+	return m
+}
+
+// WithContentIndex makes the model index test contents in the background
+// when it starts, caching outlines at cachePath.
+func (m Model) WithContentIndex(cachePath string) Model {
+	m.contentCachePath = cachePath
+	m.indexing = true
+	return m
+}
+
+func (m Model) buildContentIndex() tea.Cmd {
+	files := make([]testfile.TestFile, len(m.allItems))
+	for i, item := range m.allItems {
+		files[i] = item.TestFile
+	}
+	cachePath := m.contentCachePath
+	return func() tea.Msg {
+		return contentIndexMsg(testfile.BuildContentIndex(files, cachePath))
 	}
 }
 
@@ -89,6 +229,9 @@ func (m Model) Init() tea.Cmd {
 	if m.animations {
 		cmds = append(cmds, tick())
 	}
+	if m.indexing {
+		cmds = append(cmds, m.buildContentIndex())
+	}
 	return tea.Batch(cmds...)
 }
 
@@ -104,72 +247,77 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
-	case tea.KeyMsg:
-		switch {
-		case key.Matches(msg, m.keyMap.Quit):
-			m.quitting = true
-			return m, tea.Quit
-
-		case key.Matches(msg, m.keyMap.SaveQuit):
-			selections := m.getSelectedFiles()
-			_ = config.SaveProjectSelections(m.projectDir, selections)
-			m.quitting = true
-			return m, tea.Quit
-
-		case key.Matches(msg, m.keyMap.Up):
-			if m.cursor > 0 {
-				m.cursor--
-			}
-			return m, nil
+	case contentIndexMsg:
+		m.contentIndex = testfile.ContentIndex(msg)
+		m.indexing = false
+		m.updateFilter()
+		return m, nil
 
-		case key.Matches(msg, m.keyMap.Down):
-			if m.cursor < len(m.filteredItems)-1 {
-				m.cursor++
-			}
+	case sequenceTimeoutMsg:
+		if msg.id != m.sequenceID {
 			return m, nil
+		}
+		return m.flushSequence()
 
-		case key.Matches(msg, m.keyMap.Select):
-			if len(m.filteredItems) > 0 && m.cursor < len(m.filteredItems) {
-				filteredItem := &m.filteredItems[m.cursor]
-				filteredItem.Selected = !filteredItem.Selected
+	case customActionFinishedMsg:
+		if msg.err != nil {
+			m.notice = msg.title + " failed: " + msg.err.Error()
+		} else {
+			m.notice = msg.title + " finished"
+		}
+		m.previews = map[string]*filePreview{}
+		return m, nil
 
-				for i := range m.allItems {
-					if m.allItems[i].TestFile.Path == filteredItem.TestFile.Path {
-						m.allItems[i].Selected = filteredItem.Selected
-						break
-					}
-				}
-			}
-			return m, nil
+	case editorFinishedMsg:
+		if msg.err != nil {
+			m.notice = "Editor failed: " + msg.err.Error()
+		}
+		// The file may have changed while it was open.
+		m.previews = map[string]*filePreview{}
+		return m, nil
 
-		case key.Matches(msg, m.keyMap.SelectAll):
-			for i := range m.filteredItems {
-				m.filteredItems[i].Selected = true
-				for j := range m.allItems {
-					if m.allItems[j].TestFile.Path == m.filteredItems[i].TestFile.Path {
-						m.allItems[j].Selected = true
-						break
-					}
-				}
+	case tea.KeyMsg:
+		m.notice = ""
+		m.listScrolled = false
+		if m.promptKind != promptNone {
+			return m.updatePrompt(msg)
+		}
+		if m.overlay != overlayNone {
+			return m.updateOverlay(msg)
+		}
+		if handled, cmd := m.updateSequence(msg); handled {
+			return m, cmd
+		}
+		if len(m.pendingKeys) > 0 {
+			// The key broke the sequence: handle every key of it on its own.
+			m.pendingKeys = append(m.pendingKeys, msg)
+			return m.flushSequence()
+		}
+		if m.modal && m.mode == modeNormal {
+			if handled, cmd := m.updateNormal(msg); handled {
+				return m, cmd
 			}
+		} else if m.modal && msg.Type == tea.KeyEsc {
+			m.enterNormal()
 			return m, nil
+		}
 
-		case key.Matches(msg, m.keyMap.DeselectAll):
-			for i := range m.allItems {
-				m.allItems[i].Selected = false
-			}
-			for i := range m.filteredItems {
-				m.filteredItems[i].Selected = false
-			}
+		action := m.keyMap.Action(msg)
+		if action == actionHelp && msg.Type == tea.KeyRunes && m.searchInput.Focused() && m.searchInput.Value() != "" {
+			// "?" is also a glob wildcard, so it only opens help while the
+			// query is empty.
+			action = ""
+		}
+		if action != "" {
+			return m, m.handleAction(action)
+		}
+		if m.modal && m.mode == modeNormal {
 			return m, nil
-
-		case key.Matches(msg, m.keyMap.Run):
-			m.filesToRun = m.getSelectedFiles()
-			_ = config.SaveProjectSelections(m.projectDir, m.filesToRun)
-			m.quitting = true
-			return m, tea.Quit
 		}
 
+	case tea.MouseMsg:
+		return m, m.updateMouse(msg)
+
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
@@ -182,110 +330,181 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	cmds = append(cmds, cmd)
 
 	if m.searchInput.Value() != prevValue {
+		m.historyIndex = len(m.searchHistory)
 		m.updateFilter()
 	}
 
 	return m, tea.Batch(cmds...)
 }
 
-func (m *Model) updateFilter() {
-	query := strings.TrimSpace(m.searchInput.Value())
-	failedOnly := false
-	if strings.Contains(strings.ToLower(query), "@failed") {
-		failedOnly = true
-		query = strings.ReplaceAll(strings.ToLower(query), "@failed", "")
-	}
-
-	if strings.TrimSpace(query) == "" {
-		m.filteredItems = make([]Item, 0, len(m.allItems))
-		for _, item := range m.allItems {
-			if failedOnly && !item.Failed {
-				continue
-			}
-			m.filteredItems = append(m.filteredItems, item)
+// handleAction performs a key map action, whether it came from a single key
+// or a key sequence.
+func (m *Model) handleAction(action string) tea.Cmd {
+	switch action {
+	case actionQuit:
+		m.saveSession()
+		m.quitting = true
+		return tea.Quit
+
+	case actionSaveQuit:
+		selections := m.getSelectedFiles()
+		_ = config.SaveProjectSelections(m.projectDir, selections)
+		m.saveSession()
+		m.quitting = true
+		return tea.Quit
+
+	case actionUp:
+		if m.cursor > 0 {
+			m.cursor--
 		}
-	} else {
-		tokens := strings.Fields(strings.ToLower(query))
 
-		m.filteredItems = make([]Item, 0)
-		for _, item := range m.allItems {
-			if failedOnly && !item.Failed {
-				continue
-			}
+	case actionDown:
+		if m.cursor < m.rowCount()-1 {
+			m.cursor++
+		}
 
-			pathLower := strings.ToLower(item.TestFile.Path)
+	case actionSelect:
+		m.changeSelection("toggle", m.toggleCursorSelection)
 
-			allMatch := true
-			for _, token := range tokens {
-				if !fuzzyContains(pathLower, token) {
-					allMatch = false
-					break
-				}
-			}
+	case actionToggleView:
+		m.toggleView()
+
+	case actionExpand:
+		m.setCollapsed(false)
+
+	case actionCollapse:
+		m.setCollapsed(true)
 
-			if allMatch {
-				m.filteredItems = append(m.filteredItems, item)
+	case actionOpen:
+		return m.openCursorFile()
+
+	case actionPreview:
+		m.togglePreview()
+
+	case actionPreviewUp:
+		m.scrollPreview(-m.halfPage())
+
+	case actionPreviewDown:
+		m.scrollPreview(m.halfPage())
+
+	case actionSelectAll:
+		m.changeSelection("select all", func() {
+			for i := range m.filteredItems {
+				m.setItemSelected(i, true)
 			}
-		}
+		})
+
+	case actionDeselectAll:
+		m.changeSelection("deselect all", func() {
+			m.applySelection(nil)
+		})
+
+	case actionInvert:
+		m.invertVisible()
+
+	case actionDeselectVisible:
+		m.deselectVisible()
+
+	case actionMark:
+		m.setMark()
+
+	case actionSelectRange:
+		m.selectMarkedRange()
+
+	case actionSelectGlob:
+		return m.openPrompt(promptSelectGlob, "Select files matching: ")
+
+	case actionSort:
+		m.cycleSort()
+
+	case actionHistoryPrev:
+		m.recallHistory(-1)
+
+	case actionHistoryNext:
+		m.recallHistory(1)
 
-		if len(m.filteredItems) > 1 {
-			sortByRelevance(m.filteredItems, tokens)
+	case actionPin:
+		m.togglePin()
+
+	case actionHide:
+		m.toggleHide()
+
+	case actionUndo:
+		m.undo()
+
+	case actionRedo:
+		m.redo()
+
+	case actionNextSet:
+		m.changeSelection("set switch", m.cycleSet)
+
+	case actionSaveSet:
+		cmd := m.openPrompt(promptSaveSet, "Save selection as: ")
+		if name := m.ActiveSet(); name != "" {
+			m.prompt.SetValue(name)
+			m.prompt.CursorEnd()
 		}
-	}
+		return cmd
 
-	if m.cursor >= len(m.filteredItems) {
-		m.cursor = max(0, len(m.filteredItems)-1)
-	}
-}
+	case actionHelp:
+		m.openHelp()
 
-func fuzzyContains(text, pattern string) bool {
-	if strings.Contains(text, pattern) {
-		return true
-	}
+	case actionPalette:
+		return m.openPalette()
+
+	case actionRun:
+		m.filesToRun = m.getSelectedFiles()
+		_ = config.SaveProjectSelections(m.projectDir, m.filesToRun)
+		m.saveSession()
+		m.quitting = true
+		return tea.Quit
 
-	patternIdx := 0
-	for i := 0; i < len(text) && patternIdx < len(pattern); i++ {
-		if text[i] == pattern[patternIdx] {
-			patternIdx++
+	default:
+		if a, ok := m.customActions[action]; ok {
+			return m.runCustomAction(a)
 		}
 	}
-	return patternIdx == len(pattern)
+	return nil
 }
 
-func sortByRelevance(items []Item, tokens []string) {
-	type scored struct {
-		item  Item
-		score int
+func (m *Model) updateFilter() {
+	q, err := search.Parse(m.searchInput.Value())
+	if err != nil {
+		m.queryErr = err.Error()
+		return
 	}
-
-	scoredItems := make([]scored, len(items))
-	for i, item := range items {
-		pathLower := strings.ToLower(item.TestFile.Path)
-		score := 0
-
-		for _, token := range tokens {
-			if strings.Contains(pathLower, token) {
-				score += 100
-				if strings.Contains(pathLower, "/"+token) || strings.Contains(pathLower, "_"+token) {
-					score += 50
-				}
-			} else {
-				score += 10
-			}
-		}
-
-		score -= len(item.TestFile.Path) / 10
-
-		scoredItems[i] = scored{item: item, score: score}
+	m.queryErr = ""
+
+	matches := q.Apply(searchCandidates(m.allItems, m.contentIndex))
+	m.sortMatches(matches)
+	m.filteredItems = make([]Item, len(matches))
+	for i, match := range matches {
+		item := m.allItems[match.Index]
+		item.MatchedIndexes = match.Positions
+		item.Detail, item.DetailLine, item.DetailIndexes = match.Detail, match.Line, match.DetailPositions
+		m.filteredItems[i] = item
 	}
+	m.refreshTree()
 
-	sort.Slice(scoredItems, func(i, j int) bool {
-		return scoredItems[i].score > scoredItems[j].score
-	})
+	if m.cursor >= m.rowCount() {
+		m.cursor = max(0, m.rowCount()-1)
+	}
+}
 
-	for i, s := range scoredItems {
-		items[i] = s.item
+func searchCandidates(items []Item, index testfile.ContentIndex) []search.Candidate {
+	candidates := make([]search.Candidate, len(items))
+	for i, item := range items {
+		candidates[i] = search.Candidate{
+			Path:     item.TestFile.Path,
+			Failed:   item.Failed,
+			Selected: item.Selected,
+			Changed:  item.Changed,
+			Pinned:   item.Pinned,
+			Hidden:   item.Hidden,
+			Content:  search.OutlineContent(index[item.TestFile.Path]),
+		}
 	}
+	return candidates
 }
 
 func (m *Model) getSelectedFiles() []string {
@@ -317,24 +536,47 @@ func (m Model) getAnimatedTitle() string {
 	return titleStyle.Render(cursor + " " + titleText)
 }
 
-func (m Model) View() string {
-	if m.quitting {
-		if len(m.filesToRun) > 0 {
-			return fmt.Sprintf("\n  Running %d test file(s)...\n\n", len(m.filesToRun))
-		}
-		return ""
-	}
+// viewLayout is where View places the list and the preview, so that mouse
+// events can be mapped back to rows.
+type viewLayout struct {
+	// header is everything above the list: title, search box and notices.
+	header string
+	// listTop is the screen line of the list box's top border.
+	listTop      int
+	listHeight   int
+	listWidth    int
+	previewWidth int
+	// heights are the line counts of every row; start and end bound the
+	// rows that are visible.
+	heights    []int
+	start, end int
+}
 
+func (m Model) layout() viewLayout {
 	var b strings.Builder
 
 	// Animated title
 	b.WriteString(m.getAnimatedTitle())
 	b.WriteString("\n\n")
 
-	b.WriteString(searchBoxStyle.Render(m.searchInput.View()))
-	b.WriteString("\n\n")
-
+	if m.promptKind != promptNone {
+		b.WriteString(searchBoxStyle.Render(m.prompt.View()))
+	} else {
+		b.WriteString(searchBoxStyle.Render(m.searchInput.View()))
+	}
+	b.WriteString("\n")
 	listHeight := m.height - 12
+	if m.queryErr != "" {
+		b.WriteString(errorStyle.Render("  ⚠ Invalid query, " + m.queryErr))
+		b.WriteString("\n")
+		listHeight--
+	} else if m.indexing && strings.HasPrefix(strings.TrimSpace(m.searchInput.Value()), search.ContentPrefix) {
+		b.WriteString(noticeStyle.Render("  Indexing test contents…"))
+		b.WriteString("\n")
+		listHeight--
+	}
+	b.WriteString("\n")
+
 	if listHeight < 5 {
 		listHeight = 5
 	}
@@ -343,6 +585,56 @@ func (m Model) View() string {
 	if listWidth < 40 {
 		listWidth = 40
 	}
+	// The preview takes the right-hand side; both boxes add 2 border columns
+	// and are separated by a space.
+	previewWidth := 0
+	if m.showPreview() {
+		total := listWidth
+		listWidth = max(total*45/100, 30)
+		previewWidth = total - listWidth - 3
+	}
+
+	// Content matches take two lines: the path and the matching test.
+	heights := make([]int, m.rowCount())
+	for i := range heights {
+		heights[i] = 1
+		if item := m.rowItem(i); item >= 0 && m.filteredItems[item].Detail != "" {
+			heights[i] = 2
+		}
+	}
+	start, end := visibleWindow(heights, m.cursor, listHeight)
+	if m.listScrolled {
+		start, end = scrolledWindow(heights, m.listOffset, listHeight)
+	}
+
+	return viewLayout{
+		header:       b.String(),
+		listTop:      appStyle.GetPaddingTop() + strings.Count(b.String(), "\n"),
+		listHeight:   listHeight,
+		listWidth:    listWidth,
+		previewWidth: previewWidth,
+		heights:      heights,
+		start:        start,
+		end:          end,
+	}
+}
+
+func (m Model) View() string {
+	if m.quitting {
+		if len(m.filesToRun) > 0 {
+			return fmt.Sprintf("\n  Running %d test file(s)...\n\n", len(m.filesToRun))
+		}
+		return ""
+	}
+	if m.overlay != overlayNone {
+		return m.viewOverlay()
+	}
+
+	layout := m.layout()
+	listHeight, listWidth, previewWidth := layout.listHeight, layout.listWidth, layout.previewWidth
+
+	var b strings.Builder
+	b.WriteString(layout.header)
 
 	if len(m.filteredItems) == 0 {
 		dots := ""
@@ -353,39 +645,35 @@ func (m Model) View() string {
 		noResults := noResultsStyle.Render(noResultsText)
 		b.WriteString(listStyle.Width(listWidth).Height(listHeight).Render(noResults))
 	} else {
-		start := 0
-		end := len(m.filteredItems)
-
-		if len(m.filteredItems) > listHeight {
-			halfHeight := listHeight / 2
-			start = m.cursor - halfHeight
-			if start < 0 {
-				start = 0
-			}
-			end = start + listHeight
-			if end > len(m.filteredItems) {
-				end = len(m.filteredItems)
-				start = end - listHeight
-				if start < 0 {
-					start = 0
-				}
-			}
-		}
+		start, end := layout.start, layout.end
 
 		var listContent strings.Builder
 		for i := start; i < end; i++ {
-			listContent.WriteString(RenderItem(m.filteredItems[i], i, m.cursor, listWidth-2, m.frame, m.animations))
+			if m.treeView {
+				listContent.WriteString(m.renderTreeRow(m.treeRows[i], i, listWidth-2))
+			} else {
+				cursor := m.cursor
+				if m.inVisualRange(i) {
+					cursor = i // rows in a visual range render like the cursor row
+				}
+				listContent.WriteString(RenderItem(m.filteredItems[i], i, cursor, listWidth-2, m.frame, m.animations))
+			}
 			if i < end-1 {
 				listContent.WriteString("\n")
 			}
 		}
 
 		// Keep the list container height stable even when only a few items are visible.
-		b.WriteString(listStyle.Width(listWidth).Height(listHeight).Render(listContent.String()))
+		list := listStyle.Width(listWidth).Height(listHeight).Render(listContent.String())
+		if previewWidth > 0 {
+			list = lipgloss.JoinHorizontal(lipgloss.Top, list, " ", m.renderPreview(previewWidth, listHeight))
+		}
+		b.WriteString(list)
 	}
 
 	selectedCount := 0
 	failedCount := 0
+	hiddenCount := 0
 	for _, item := range m.allItems {
 		if item.Selected {
 			selectedCount++
@@ -393,6 +681,9 @@ func (m Model) View() string {
 		if item.Failed {
 			failedCount++
 		}
+		if item.Hidden {
+			hiddenCount++
+		}
 	}
 
 	var statusIcon string
@@ -404,20 +695,96 @@ func (m Model) View() string {
 		}
 	}
 
+	if label := m.modeLabel(); label != "" {
+		statusIcon = label + " " + statusIcon
+	}
 	status := fmt.Sprintf("%s%d selected • %d failing • %d/%d shown", statusIcon, selectedCount, failedCount, len(m.filteredItems), len(m.allItems))
+	if hiddenCount > 0 {
+		status += fmt.Sprintf(" • %d hidden", hiddenCount)
+	}
+	if name := m.ActiveSet(); name != "" {
+		status += " • set: " + name
+	}
+	if m.sortMode != "" {
+		status += " • sort: " + sortLabels[m.sortMode]
+	}
 	b.WriteString("\n")
 	b.WriteString(statusStyle.Render(status))
+	if m.notice != "" {
+		b.WriteString("  ")
+		b.WriteString(noticeStyle.Render(m.notice))
+	}
 
 	b.WriteString("\n")
 	help := m.keyMap.ShortHelp(m.compactHelp)
+	if m.modal && m.mode == modeNormal {
+		help = normalHelp + helpSeparator + m.keyMap.ShortHelp(true)
+	}
+	if len(m.pendingKeys) > 0 {
+		help = m.pendingHelp()
+	}
 	b.WriteString(helpStyle.Render(help))
 
 	return appStyle.Render(b.String())
 }
 
-func max(a, b int) int {
-	if a > b {
-		return a
+// halfPage is how far preview scrolling and half-page motions move.
+func (m Model) halfPage() int {
+	return max((m.height-12)/2, 1)
+}
+
+// rowItem returns the filteredItems index shown on row i, or -1 for a
+// directory row.
+func (m Model) rowItem(i int) int {
+	if m.treeView {
+		return m.treeRows[i].item
+	}
+	return i
+}
+
+// visibleWindow picks the rows to draw so that the cursor row is visible,
+// centred when possible, and the rows fit in maxLines.
+func visibleWindow(heights []int, cursor, maxLines int) (int, int) {
+	if len(heights) == 0 {
+		return 0, 0
+	}
+	cursor = min(max(cursor, 0), len(heights)-1)
+	start, end := cursor, cursor+1
+	used := heights[cursor]
+	for start > 0 && used+heights[start-1] <= maxLines/2 {
+		start--
+		used += heights[start]
+	}
+	for end < len(heights) && used+heights[end] <= maxLines {
+		used += heights[end]
+		end++
+	}
+	for start > 0 && used+heights[start-1] <= maxLines {
+		start--
+		used += heights[start]
+	}
+	return start, end
+}
+
+// scrolledWindow picks the rows to draw from start on, moving start back
+// when the rows below it would leave the list part empty.
+func scrolledWindow(heights []int, start, maxLines int) (int, int) {
+	start = min(max(start, 0), lastWindowStart(heights, maxLines))
+	end, used := start, 0
+	for end < len(heights) && (end == start || used+heights[end] <= maxLines) {
+		used += heights[end]
+		end++
+	}
+	return start, end
+}
+
+// lastWindowStart is the first row of the window that ends with the last
+// row.
+func lastWindowStart(heights []int, maxLines int) int {
+	start, used := len(heights), 0
+	for start > 0 && (start == len(heights) || used+heights[start-1] <= maxLines) {
+		start--
+		used += heights[start]
 	}
-	return b
+	return start
 }
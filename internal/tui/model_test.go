@@ -1,31 +1,71 @@
 package tui
 
 import (
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/samrobinsonsauce/eztest/internal/config"
 	"github.com/samrobinsonsauce/eztest/internal/testfile"
 )
 
-func testModelForFailures() Model {
-	files := []testfile.TestFile{
-		{Path: "test/user_test.exs"},
-		{Path: "test/api_test.exs"},
-		{Path: "test/auth_test.exs"},
-	}
-
-	return NewModel(
-		files,
-		"/tmp/project",
-		[]string{"test/user_test.exs"},
-		[]string{"test/api_test.exs", "test/auth_test.exs"},
-		DefaultKeyMap(),
-		config.UISettings{Animations: false},
-	)
+// testModelConfig holds what newTestModel passes to NewModel besides the
+// files.
+type testModelConfig struct {
+	selected, failed []string
+	keyMap           KeyMap
+}
+
+type testModelOption func(*testModelConfig)
+
+func withSelected(paths ...string) testModelOption {
+	return func(c *testModelConfig) { c.selected = paths }
+}
+
+func withFailed(paths ...string) testModelOption {
+	return func(c *testModelConfig) { c.failed = paths }
+}
+
+func withKeyMap(keyMap KeyMap) testModelOption {
+	return func(c *testModelConfig) { c.keyMap = keyMap }
+}
+
+// newTestModel builds a model over paths in a temporary project directory,
+// with saved state kept in a temporary home (see isolateState).
+func newTestModel(t *testing.T, paths []string, opts ...testModelOption) Model {
+	t.Helper()
+	isolateState(t)
+	c := testModelConfig{keyMap: DefaultKeyMap()}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	files := make([]testfile.TestFile, len(paths))
+	for i, p := range paths {
+		files[i] = testfile.TestFile{Path: p}
+	}
+	return NewModel(files, t.TempDir(), c.selected, c.failed, c.keyMap, config.UISettings{})
+}
+
+// isolateState points HOME and the XDG directories at a temporary directory,
+// so actions that save state never write to the developer's own.
+func isolateState(t *testing.T) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("XDG_STATE_HOME", filepath.Join(home, ".state"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(home, ".cache"))
+}
+
+func testModelForFailures(t *testing.T) Model {
+	return newTestModel(t,
+		[]string{"test/user_test.exs", "test/api_test.exs", "test/auth_test.exs"},
+		withSelected("test/user_test.exs"),
+		withFailed("test/api_test.exs", "test/auth_test.exs"))
 }
 
 func TestNewModelMarksFailedItems(t *testing.T) {
-	m := testModelForFailures()
+	m := testModelForFailures(t)
 
 	failed := map[string]bool{}
 	for _, item := range m.allItems {
@@ -44,7 +84,7 @@ func TestNewModelMarksFailedItems(t *testing.T) {
 }
 
 func TestUpdateFilterFailedTokenShowsOnlyFailed(t *testing.T) {
-	m := testModelForFailures()
+	m := testModelForFailures(t)
 	m.searchInput.SetValue("@failed")
 	m.updateFilter()
 
@@ -60,7 +100,7 @@ func TestUpdateFilterFailedTokenShowsOnlyFailed(t *testing.T) {
 }
 
 func TestUpdateFilterFailedTokenCombinedWithQuery(t *testing.T) {
-	m := testModelForFailures()
+	m := testModelForFailures(t)
 	m.searchInput.SetValue("@failed api")
 	m.updateFilter()
 
@@ -71,3 +111,66 @@ func TestUpdateFilterFailedTokenCombinedWithQuery(t *testing.T) {
 		t.Fatalf("unexpected filtered file: got %q want %q", got, want)
 	}
 }
+
+func TestUpdateFilterKeepsResultsAndReportsInvalidQuery(t *testing.T) {
+	m := testModelForFailures(t)
+	m.searchInput.SetValue("api")
+	m.updateFilter()
+	before := len(m.filteredItems)
+
+	m.searchInput.SetValue("api /[/")
+	m.updateFilter()
+	if m.queryErr == "" {
+		t.Fatal("expected a query error for an invalid regex")
+	}
+	if len(m.filteredItems) != before {
+		t.Fatalf("expected previous results to stay visible, got %d items want %d", len(m.filteredItems), before)
+	}
+	if view := m.View(); !strings.Contains(view, "Invalid query") {
+		t.Fatalf("expected the error under the search box, got:\n%s", view)
+	}
+
+	m.searchInput.SetValue("api")
+	m.updateFilter()
+	if m.queryErr != "" {
+		t.Fatalf("expected error to clear, got %q", m.queryErr)
+	}
+}
+
+func TestWithChangedBacksChangedToken(t *testing.T) {
+	m := testModelForFailures(t).WithChanged([]string{"test/auth_test.exs"})
+	m.searchInput.SetValue("@changed")
+	m.updateFilter()
+
+	if len(m.filteredItems) != 1 || m.filteredItems[0].TestFile.Path != "test/auth_test.exs" {
+		t.Fatalf("unexpected @changed results: %+v", m.filteredItems)
+	}
+}
+
+func TestContentSearchShowsMatchingTest(t *testing.T) {
+	m := testModelForFailures(t).WithContentIndex("")
+	m.searchInput.SetValue("#expired token")
+	m.updateFilter()
+	if !strings.Contains(m.View(), "Indexing test contents") {
+		t.Fatalf("expected an indexing notice before the index arrives")
+	}
+
+	updated, _ := m.Update(contentIndexMsg(testfile.ContentIndex{
+		"test/auth_test.exs": {
+			{Kind: testfile.OutlineModule, Name: "MyApp.AuthTest", Line: 1},
+			{Kind: testfile.OutlineTest, Name: "rejects an expired token", Describe: "verify/1", Line: 12},
+		},
+	}))
+	m = updated.(Model)
+
+	if len(m.filteredItems) != 1 {
+		t.Fatalf("expected one content match, got %d", len(m.filteredItems))
+	}
+	item := m.filteredItems[0]
+	if item.TestFile.Path != "test/auth_test.exs" || item.DetailLine != 12 || item.Detail != "verify/1 rejects an expired token" {
+		t.Fatalf("unexpected content match %+v", item)
+	}
+	if strings.Contains(m.View(), "Indexing test contents") {
+		t.Fatalf("expected the indexing notice to clear once the index arrives")
+	}
+}
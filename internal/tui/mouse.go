@@ -0,0 +1,135 @@
+package tui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// wheelStep is how many rows one wheel notch scrolls.
+const wheelStep = 3
+
+// updateMouse handles clicks and the wheel. Clicking a row moves the cursor
+// there and clicking its checkbox also toggles it; the wheel scrolls the
+// list without moving the cursor, or the preview when the pointer is over
+// it. Clicking a numbered line
+// in the preview opens the file at that line, and clicking an entry of the
+// help line runs its action.
+func (m *Model) updateMouse(msg tea.MouseMsg) tea.Cmd {
+	if m.promptKind != promptNone {
+		return nil
+	}
+	if m.overlay != overlayNone {
+		if m.overlay == overlayHelp && msg.Button == tea.MouseButtonWheelUp {
+			m.scrollHelp(-wheelStep)
+		} else if m.overlay == overlayHelp && msg.Button == tea.MouseButtonWheelDown {
+			m.scrollHelp(wheelStep)
+		}
+		return nil
+	}
+	layout := m.layout()
+	left := appStyle.GetPaddingLeft()
+	previewLeft := left + layout.listWidth + listStyle.GetHorizontalBorderSize() + 1
+	overPreview := layout.previewWidth > 0 && msg.X >= previewLeft
+
+	switch msg.Button {
+	case tea.MouseButtonWheelUp, tea.MouseButtonWheelDown:
+		delta := wheelStep
+		if msg.Button == tea.MouseButtonWheelUp {
+			delta = -delta
+		}
+		if overPreview {
+			m.scrollPreview(delta)
+		} else {
+			m.scrollList(delta, layout)
+		}
+		return nil
+	case tea.MouseButtonLeft:
+		if msg.Action != tea.MouseActionPress {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	if msg.Y == layout.helpTop() {
+		if action := m.helpActionAt(msg.X - left); action != "" {
+			m.listScrolled = false
+			return m.handleAction(action)
+		}
+		return nil
+	}
+
+	line := msg.Y - layout.listTop - listStyle.GetBorderTopSize()
+	if line < 0 || line >= layout.listHeight {
+		return nil
+	}
+	if overPreview {
+		_, fileLines := m.previewLines()
+		if m.previewPath == m.previewKey() {
+			line += m.previewScroll
+		}
+		if line < len(fileLines) && fileLines[line] > 0 {
+			return m.openCursorFileAt(fileLines[line])
+		}
+		return nil
+	}
+
+	row := layout.rowAt(line)
+	if row < 0 {
+		return nil
+	}
+	m.cursor = row
+	// Rows start with the cursor marker and a space, then the checkbox.
+	checkbox := left + listStyle.GetBorderLeftSize() + listStyle.GetPaddingLeft() + itemStyle.GetPaddingLeft() + 2
+	if msg.X >= checkbox && msg.X < checkbox+lipgloss.Width("[ ]") {
+		m.changeSelection("toggle", m.toggleCursorSelection)
+	}
+	return nil
+}
+
+// scrollList moves the visible rows by delta, starting from the window that
+// is currently shown.
+func (m *Model) scrollList(delta int, layout viewLayout) {
+	if !m.listScrolled {
+		m.listOffset = layout.start
+	}
+	m.listOffset = min(max(m.listOffset+delta, 0), lastWindowStart(layout.heights, layout.listHeight))
+	m.listScrolled = true
+}
+
+// helpTop is the screen line of the help line, below the list box and the
+// status line.
+func (l viewLayout) helpTop() int {
+	listBottom := l.listTop + l.listHeight + listStyle.GetVerticalBorderSize()
+	return listBottom + statusStyle.GetMarginTop() + 1 + helpStyle.GetMarginTop()
+}
+
+// rowAt returns the row shown on a line of the list's content, or -1.
+func (l viewLayout) rowAt(line int) int {
+	for i := l.start; i < l.end; i++ {
+		if line < l.heights[i] {
+			return i
+		}
+		line -= l.heights[i]
+	}
+	return -1
+}
+
+// helpActionAt returns the action whose help line entry covers column x.
+func (m Model) helpActionAt(x int) string {
+	if len(m.pendingKeys) > 0 {
+		return ""
+	}
+	col, compact := 0, m.compactHelp
+	if m.modal && m.mode == modeNormal {
+		col, compact = lipgloss.Width(normalHelp+helpSeparator), true
+	}
+	for _, ab := range m.keyMap.shortHelpBindings(compact) {
+		width := lipgloss.Width(helpEntry(ab.Binding))
+		if x >= col && x < col+width {
+			return ab.Action
+		}
+		col += width + lipgloss.Width(helpSeparator)
+	}
+	return ""
+}
@@ -0,0 +1,105 @@
+package tui
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// screenPos finds text in the rendered view and returns its cell position.
+func screenPos(t *testing.T, m Model, text string) (int, int) {
+	t.Helper()
+	for y, line := range strings.Split(m.View(), "\n") {
+		if i := strings.Index(line, text); i >= 0 {
+			return lipgloss.Width(line[:i]), y
+		}
+	}
+	t.Fatalf("%q not found in view:\n%s", text, m.View())
+	return 0, 0
+}
+
+func click(m Model, x, y int) (Model, tea.Cmd) {
+	updated, cmd := m.Update(tea.MouseMsg{X: x, Y: y, Button: tea.MouseButtonLeft, Action: tea.MouseActionPress})
+	return updated.(Model), cmd
+}
+
+func TestMouseClickMovesCursorAndTogglesCheckbox(t *testing.T) {
+	var paths []string
+	for _, name := range []string{"a", "b", "c", "d", "e", "f"} {
+		paths = append(paths, "test/"+name+"_test.exs")
+	}
+	m := newTestModel(t, paths)
+	m.width, m.height = 80, 30
+
+	x, y := screenPos(t, m, "test/c_test.exs")
+	m, _ = click(m, x, y)
+	if m.cursor != 2 || len(m.getSelectedFiles()) != 0 {
+		t.Fatalf("expected a click on the path to only move the cursor, got cursor %d", m.cursor)
+	}
+
+	_, y = screenPos(t, m, "test/e_test.exs")
+	x, _ = screenPos(t, m, "[ ]")
+	m, _ = click(m, x+1, y)
+	if got := m.getSelectedFiles(); m.cursor != 4 || !reflect.DeepEqual(got, []string{"test/e_test.exs"}) {
+		t.Fatalf("expected a click on the checkbox to toggle the row, got cursor %d selected %v", m.cursor, got)
+	}
+
+	m.cursor = 1
+	x, y = screenPos(t, m, "tab: select")
+	m, _ = click(m, x, y)
+	if got := m.getSelectedFiles(); !reflect.DeepEqual(got, []string{"test/b_test.exs", "test/e_test.exs"}) {
+		t.Fatalf("expected clicking the help entry to run its action, got %v", got)
+	}
+}
+
+func TestMouseWheelScrollsListWithoutMovingCursor(t *testing.T) {
+	var paths []string
+	for i := 0; i < 40; i++ {
+		paths = append(paths, fmt.Sprintf("test/file_%02d_test.exs", i))
+	}
+	m := newTestModel(t, paths)
+	m.width, m.height = 80, 20
+
+	x, y := screenPos(t, m, "test/file_00_test.exs")
+	for i := 0; i < 2; i++ {
+		updated, _ := m.Update(tea.MouseMsg{X: x, Y: y, Button: tea.MouseButtonWheelDown})
+		m = updated.(Model)
+	}
+	if m.cursor != 0 {
+		t.Fatalf("expected the wheel to leave the cursor alone, got %d", m.cursor)
+	}
+	if view := m.View(); strings.Contains(view, "file_05_test.exs") || !strings.Contains(view, "file_06_test.exs") {
+		t.Fatalf("expected the list to scroll by %d rows:\n%s", 2*wheelStep, view)
+	}
+
+	_, y = screenPos(t, m, "test/file_07_test.exs")
+	m, _ = click(m, x, y)
+	if m.cursor != 7 {
+		t.Fatalf("expected a click on a scrolled row to pick it, got cursor %d", m.cursor)
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m = updated.(Model)
+	if m.listScrolled || m.cursor != 8 {
+		t.Fatalf("expected a key to bring back the cursor-following list, got cursor %d", m.cursor)
+	}
+}
+
+func TestMouseClickOnPreviewOpensFileAtLine(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "vim")
+	m := testModelForPreview(t)
+
+	x, y := screenPos(t, m, "   6       assert :error")
+	if _, cmd := click(m, x, y); cmd == nil {
+		t.Fatal("expected a click on a source line to open the editor")
+	}
+	x, y = screenPos(t, m, "Last failure")
+	if _, cmd := click(m, x, y); cmd != nil {
+		t.Fatal("expected a click on the failure heading to do nothing")
+	}
+}
@@ -0,0 +1,125 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type editorFinishedMsg struct {
+	err error
+}
+
+// WithOpenCommand sets the template used to open files, for example
+// "code -g {file}:{line}". An empty template uses $VISUAL or $EDITOR.
+func (m Model) WithOpenCommand(template string) Model {
+	m.openCommand = template
+	return m
+}
+
+// openCursorFile suspends the TUI and opens the file under the cursor, at
+// its last failure line when one was recorded.
+func (m *Model) openCursorFile() tea.Cmd {
+	return m.openCursorFileAt(0)
+}
+
+// openCursorFileAt opens the file under the cursor at line, or where
+// openCursorFile would when line is 0.
+func (m *Model) openCursorFileAt(line int) tea.Cmd {
+	i := m.cursorItem()
+	if i < 0 {
+		return nil
+	}
+	item := m.filteredItems[i]
+	file := item.TestFile.AbsolutePath
+	if file == "" {
+		file = filepath.Join(m.projectDir, item.TestFile.Path)
+	}
+	if line == 0 {
+		line = FailureLine(m.failureMessages[item.TestFile.Path], item.TestFile.Path)
+	}
+	if line == 0 {
+		line = item.DetailLine
+	}
+
+	argv, err := OpenCommand(m.openCommand, os.Getenv, file, line)
+	if err != nil {
+		m.notice = err.Error()
+		return nil
+	}
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Dir = m.projectDir
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return editorFinishedMsg{err: err}
+	})
+}
+
+// OpenCommand builds the command that opens file at line (0 when unknown).
+// A template has {file} and {line} replaced in each word and gets the file
+// appended when it has no {file}. Without a template the editor from
+// $VISUAL or $EDITOR is used with its own line syntax.
+func OpenCommand(template string, getenv func(string) string, file string, line int) ([]string, error) {
+	if words := strings.Fields(template); len(words) > 0 {
+		lineText := strconv.Itoa(max(line, 1))
+		hasFile := false
+		argv := make([]string, len(words))
+		for i, word := range words {
+			hasFile = hasFile || strings.Contains(word, "{file}")
+			argv[i] = strings.NewReplacer("{file}", file, "{line}", lineText).Replace(word)
+		}
+		if !hasFile {
+			argv = append(argv, file)
+		}
+		return argv, nil
+	}
+
+	editor := strings.Fields(getenv("VISUAL"))
+	if len(editor) == 0 {
+		editor = strings.Fields(getenv("EDITOR"))
+	}
+	if len(editor) == 0 {
+		return nil, fmt.Errorf("set $EDITOR or open.command to open files")
+	}
+	return append(editor, editorLineArgs(filepath.Base(editor[0]), file, line)...), nil
+}
+
+// editorLineArgs returns the arguments that open file at line for a known
+// editor. Unknown editors just get the file.
+func editorLineArgs(editor, file string, line int) []string {
+	if line <= 0 {
+		return []string{file}
+	}
+	l := strconv.Itoa(line)
+	switch strings.TrimSuffix(editor, ".exe") {
+	case "vi", "vim", "nvim", "gvim", "mvim", "nano", "emacs", "emacsclient", "micro", "kak", "mg", "joe", "ne":
+		return []string{"+" + l, file}
+	case "code", "code-insiders", "codium", "cursor", "windsurf":
+		return []string{"-g", file + ":" + l}
+	case "subl", "zed", "hx", "helix":
+		return []string{file + ":" + l}
+	case "idea", "goland", "webstorm", "rubymine", "pycharm", "clion":
+		return []string{"--line", l, file}
+	}
+	return []string{file}
+}
+
+// FailureLine returns the first line of path named in an ExUnit failure
+// message, which is the failing test's own location, or 0.
+func FailureLine(message, path string) int {
+	if message == "" {
+		return 0
+	}
+	pattern := regexp.MustCompile(`(?:^|[\s(])(?:\S*/)?` + regexp.QuoteMeta(path) + `:(\d+)`)
+	m := pattern.FindStringSubmatch(message)
+	if m == nil {
+		return 0
+	}
+	line, _ := strconv.Atoi(m[1])
+	return line
+}
@@ -0,0 +1,69 @@
+package tui
+
+import (
+	"reflect"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestOpenCommand(t *testing.T) {
+	env := func(values map[string]string) func(string) string {
+		return func(name string) string { return values[name] }
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		env      map[string]string
+		line     int
+		want     []string
+	}{
+		{"template", "code -g {file}:{line}", nil, 12, []string{"code", "-g", "/p/test/a_test.exs:12"}},
+		{"template without line", "tmux split-window -h nvim +{line}", nil, 0, []string{"tmux", "split-window", "-h", "nvim", "+1", "/p/test/a_test.exs"}},
+		{"visual wins", "", map[string]string{"VISUAL": "nvim", "EDITOR": "nano"}, 12, []string{"nvim", "+12", "/p/test/a_test.exs"}},
+		{"editor with args", "", map[string]string{"EDITOR": "/usr/local/bin/code --wait"}, 7, []string{"/usr/local/bin/code", "--wait", "-g", "/p/test/a_test.exs:7"}},
+		{"colon syntax", "", map[string]string{"EDITOR": "hx"}, 3, []string{"hx", "/p/test/a_test.exs:3"}},
+		{"unknown editor", "", map[string]string{"EDITOR": "ed"}, 3, []string{"ed", "/p/test/a_test.exs"}},
+		{"no line", "", map[string]string{"EDITOR": "vim"}, 0, []string{"vim", "/p/test/a_test.exs"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := OpenCommand(tt.template, env(tt.env), "/p/test/a_test.exs", tt.line)
+			if err != nil {
+				t.Fatalf("OpenCommand returned error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %q want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := OpenCommand("", env(nil), "/p/test/a_test.exs", 1); err == nil {
+		t.Fatal("expected an error when no editor is configured")
+	}
+}
+
+func TestFailureLine(t *testing.T) {
+	message := "1) test works (MyApp.ATest)\n   /tmp/p/test/a_test.exs:12\n   ** (RuntimeError) boom\n   stacktrace:\n     test/a_test.exs:14: (test)"
+	if got := FailureLine(message, "test/a_test.exs"); got != 12 {
+		t.Fatalf("expected the test's line 12, got %d", got)
+	}
+	if got := FailureLine(message, "test/b_test.exs"); got != 0 {
+		t.Fatalf("expected no line for another file, got %d", got)
+	}
+}
+
+func TestOpenWithoutEditorShowsNotice(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "")
+
+	m := testModelForFailures(t)
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlO})
+	if cmd != nil {
+		t.Fatal("expected no command without an editor")
+	}
+	if got := updated.(Model).notice; got == "" {
+		t.Fatal("expected a notice explaining how to set an editor")
+	}
+}
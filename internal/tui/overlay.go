@@ -0,0 +1,292 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/sahilm/fuzzy"
+)
+
+type overlayKind int
+
+const (
+	overlayNone overlayKind = iota
+	overlayHelp
+	overlayPalette
+)
+
+// normalModeHelp lists the fixed keys of modal normal mode for the help
+// overlay.
+var normalModeHelp = [][2]string{
+	{"j/k", "move"},
+	{"ctrl+d/ctrl+u", "half page down/up"},
+	{"gg/G", "top/bottom"},
+	{"x/space", "toggle"},
+	{"V", "visual range"},
+	{"n/N", "next/previous failure"},
+	{"u/ctrl+r", "undo/redo"},
+	{"/", "search"},
+	{"i/a", "insert mode"},
+	{"esc", "leave visual mode"},
+}
+
+// paletteEntry is one command in the palette: a key map action or a named
+// set to switch to.
+type paletteEntry struct {
+	title  string
+	key    string
+	action string
+	set    int
+}
+
+func (m *Model) openHelp() {
+	m.overlay = overlayHelp
+	m.overlayScroll = 0
+}
+
+func (m *Model) openPalette() tea.Cmd {
+	m.overlay = overlayPalette
+	m.palette = newPromptInput("> ")
+	m.paletteCursor = 0
+	m.filterPalette()
+	return textinput.Blink
+}
+
+func (m *Model) scrollHelp(delta int) {
+	m.overlayScroll = min(max(m.overlayScroll+delta, 0), max(len(m.helpOverlayLines())-1, 0))
+}
+
+// updateOverlay handles a key while the help overlay or the palette is open.
+func (m Model) updateOverlay(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.overlay == overlayHelp {
+		switch msg.String() {
+		case "up", "k":
+			m.scrollHelp(-1)
+		case "down", "j":
+			m.scrollHelp(1)
+		case "pgup":
+			m.scrollHelp(-m.halfPage())
+		case "pgdown":
+			m.scrollHelp(m.halfPage())
+		default:
+			m.overlay = overlayNone
+		}
+		return m, nil
+	}
+
+	switch msg.Type {
+	case tea.KeyEsc, tea.KeyCtrlC:
+		m.overlay = overlayNone
+		return m, nil
+	case tea.KeyEnter:
+		m.overlay = overlayNone
+		if m.paletteCursor >= len(m.paletteMatches) {
+			return m, nil
+		}
+		entry := m.paletteMatches[m.paletteCursor]
+		if entry.action == "" {
+			m.changeSelection("set switch", func() { m.useSet(entry.set) })
+			return m, nil
+		}
+		return m, m.handleAction(entry.action)
+	case tea.KeyUp, tea.KeyCtrlK:
+		m.paletteCursor = max(m.paletteCursor-1, 0)
+		return m, nil
+	case tea.KeyDown, tea.KeyCtrlJ:
+		m.paletteCursor = min(m.paletteCursor+1, max(len(m.paletteMatches)-1, 0))
+		return m, nil
+	}
+
+	prev := m.palette.Value()
+	var cmd tea.Cmd
+	m.palette, cmd = m.palette.Update(msg)
+	if m.palette.Value() != prev {
+		m.filterPalette()
+	}
+	return m, cmd
+}
+
+// paletteEntries lists every action with its current keys, then every named
+// set, including the sets defined in config.
+func (m Model) paletteEntries() []paletteEntry {
+	var entries []paletteEntry
+	for _, ab := range m.keyMap.Bindings() {
+		help := ab.Binding.Help()
+		entries = append(entries, paletteEntry{title: help.Desc, key: help.Key, action: ab.Action})
+	}
+	for i, set := range m.sets {
+		title := "use set " + set.Name
+		if set.Origin != "" {
+			title += " (" + set.Origin + ")"
+		}
+		entries = append(entries, paletteEntry{title: title, set: i})
+	}
+	return entries
+}
+
+// filterPalette fuzzy-matches the palette query against each entry's title
+// and action name.
+func (m *Model) filterPalette() {
+	entries := m.paletteEntries()
+	query := strings.TrimSpace(m.palette.Value())
+	m.paletteCursor = 0
+	if query == "" {
+		m.paletteMatches = entries
+		return
+	}
+
+	targets := make([]string, len(entries))
+	for i, e := range entries {
+		targets[i] = e.title + " " + e.action
+	}
+	m.paletteMatches = nil
+	for _, match := range fuzzy.Find(query, targets) {
+		m.paletteMatches = append(m.paletteMatches, entries[match.Index])
+	}
+}
+
+// helpOverlayLines renders every action grouped by category with its keys.
+func (m Model) helpOverlayLines() []string {
+	groups := m.keyMap.helpGroups(m.modal)
+	keyWidth := helpKeyWidth(groups)
+
+	var lines []string
+	for i, g := range groups {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, previewHeadingStyle.Render(g.title))
+		for _, r := range g.rows {
+			lines = append(lines, "  "+cursorStyle.Render(fmt.Sprintf("%-*s", keyWidth, r[0]))+"  "+r[1])
+		}
+	}
+	return lines
+}
+
+// helpGroup is a titled list of keys and what they do.
+type helpGroup struct {
+	title string
+	rows  [][2]string
+}
+
+// helpGroups lists every action by category with its keys, followed by
+// the fixed keys of normal mode when modal is set.
+func (k KeyMap) helpGroups(modal bool) []helpGroup {
+	var groups []helpGroup
+	for _, ag := range actionGroups {
+		g := helpGroup{title: ag.Title}
+		for _, action := range ag.Actions {
+			help := k.binding(action).Help()
+			key := help.Key
+			if key == "" {
+				key = "(unbound)"
+			}
+			g.rows = append(g.rows, [2]string{key, help.Desc})
+		}
+		groups = append(groups, g)
+	}
+	if len(k.custom) > 0 {
+		g := helpGroup{title: "Custom actions"}
+		for _, ab := range k.custom {
+			help := ab.Binding.Help()
+			key := help.Key
+			if key == "" {
+				key = "(unbound)"
+			}
+			g.rows = append(g.rows, [2]string{key, help.Desc})
+		}
+		groups = append(groups, g)
+	}
+	if modal {
+		groups = append(groups, helpGroup{title: "Normal mode", rows: normalModeHelp})
+	}
+	return groups
+}
+
+func helpKeyWidth(groups []helpGroup) int {
+	width := 0
+	for _, g := range groups {
+		for _, r := range g.rows {
+			width = max(width, lipgloss.Width(r[0]))
+		}
+	}
+	return width
+}
+
+// HelpText renders every binding by category as plain text, including the
+// normal mode keys, with each line prefixed by indent. It backs --help so
+// the listed keys always match the key map.
+func (k KeyMap) HelpText(indent string) string {
+	groups := k.helpGroups(true)
+	keyWidth := helpKeyWidth(groups)
+
+	var b strings.Builder
+	for i, g := range groups {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(indent + g.title + ":\n")
+		for _, r := range g.rows {
+			pad := keyWidth - lipgloss.Width(r[0])
+			fmt.Fprintf(&b, "%s  %s%s  %s\n", indent, r[0], strings.Repeat(" ", pad), r[1])
+		}
+	}
+	return b.String()
+}
+
+// viewOverlay renders the open overlay in place of the list.
+func (m Model) viewOverlay() string {
+	var b strings.Builder
+	b.WriteString(m.getAnimatedTitle())
+	b.WriteString("\n\n")
+
+	width := max(m.width-6, 40)
+	height := max(m.height-8, 5)
+
+	if m.overlay == overlayHelp {
+		lines := m.helpOverlayLines()
+		if m.overlayScroll < len(lines) {
+			lines = lines[m.overlayScroll:]
+		}
+		if len(lines) > height {
+			lines = lines[:height]
+		}
+		b.WriteString(listStyle.Width(width).Height(height).Render(strings.Join(lines, "\n")))
+		b.WriteString("\n")
+		b.WriteString(helpStyle.Render("↑/↓: scroll • any other key: close"))
+		return appStyle.Render(b.String())
+	}
+
+	b.WriteString(searchBoxStyle.Render(m.palette.View()))
+	b.WriteString("\n\n")
+	height -= 4
+
+	var rows []string
+	start := max(m.paletteCursor-height+1, 0)
+	for i := start; i < len(m.paletteMatches) && len(rows) < height; i++ {
+		entry := m.paletteMatches[i]
+		key := entry.key
+		if entry.action == "" {
+			key = ""
+		} else if key == "" {
+			key = "(unbound)"
+		}
+		gap := max(width-4-lipgloss.Width(entry.title)-lipgloss.Width(key), 1)
+		line := entry.title + strings.Repeat(" ", gap) + treeCountStyle.Render(key)
+		if i == m.paletteCursor {
+			rows = append(rows, selectedItemStyle.Width(width-2).Render(line))
+		} else {
+			rows = append(rows, itemStyle.Width(width-2).Render(line))
+		}
+	}
+	if len(rows) == 0 {
+		rows = append(rows, noResultsStyle.Render("No matching commands"))
+	}
+	b.WriteString(listStyle.Width(width).Height(height).Render(strings.Join(rows, "\n")))
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render("enter: run • ↑/↓: move • esc: close"))
+	return appStyle.Render(b.String())
+}
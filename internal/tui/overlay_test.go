@@ -0,0 +1,72 @@
+package tui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func testModelForOverlay(t *testing.T) Model {
+	m := newTestModel(t, []string{"test/a_test.exs", "test/b_test.exs", "test/c_test.exs"}, withSelected("test/a_test.exs")).
+		WithSets([]NamedSet{{Name: "smoke", Files: []string{"test/b_test.exs", "test/c_test.exs"}, Origin: "config"}})
+	m.width, m.height = 100, 60
+	return m
+}
+
+func typeRunes(m Model, text string) Model {
+	for _, r := range text {
+		updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		m = updated.(Model)
+	}
+	return m
+}
+
+func TestHelpOverlayGroupsBindings(t *testing.T) {
+	m := typeRunes(testModelForOverlay(t), "?")
+	if m.overlay != overlayHelp {
+		t.Fatal("expected ? to open the help overlay with an empty query")
+	}
+	view := m.View()
+	for _, want := range []string{"Navigation", "Selection", "Named sets", "tree/flat", "alt+w", "command palette"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in the help overlay, got:\n%s", want, view)
+		}
+	}
+
+	m = typeRunes(m, "x")
+	if m.overlay != overlayNone || m.searchInput.Value() != "" {
+		t.Fatal("expected any other key to close the overlay without typing it")
+	}
+
+	m = typeRunes(m, "a?")
+	if m.overlay != overlayNone || m.searchInput.Value() != "a?" {
+		t.Fatalf("expected ? to be typed into a non-empty query, got %q", m.searchInput.Value())
+	}
+}
+
+func TestPaletteRunsActionsAndSets(t *testing.T) {
+	m := testModelForOverlay(t)
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlP})
+	m = typeRunes(updated.(Model), "desel")
+	if len(m.paletteMatches) == 0 || m.paletteMatches[0].action != actionDeselectAll {
+		t.Fatalf("expected deselect all to match first, got %+v", m.paletteMatches)
+	}
+	if view := m.View(); !strings.Contains(view, "deselect all") || !strings.Contains(view, "^d") {
+		t.Fatalf("expected the palette to show the action with its key, got:\n%s", view)
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m = updated.(Model); m.overlay != overlayNone || len(m.getSelectedFiles()) != 0 {
+		t.Fatalf("expected enter to run the action, got %v", m.getSelectedFiles())
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlP})
+	m = typeRunes(updated.(Model), "smoke")
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if got := m.getSelectedFiles(); !reflect.DeepEqual(got, []string{"test/b_test.exs", "test/c_test.exs"}) || m.ActiveSet() != "smoke" {
+		t.Fatalf("expected the palette to switch to the config set, got %v", got)
+	}
+}
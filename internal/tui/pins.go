@@ -0,0 +1,99 @@
+package tui
+
+import (
+	"sort"
+
+	"github.com/samrobinsonsauce/eztest/internal/config"
+)
+
+// WithPins marks the files pinned to the top of the list and the files
+// hidden from it.
+func (m Model) WithPins(pinned, hidden []string) Model {
+	isPinned := make(map[string]bool, len(pinned))
+	for _, p := range pinned {
+		isPinned[p] = true
+	}
+	isHidden := make(map[string]bool, len(hidden))
+	for _, p := range hidden {
+		isHidden[p] = true
+	}
+
+	items := make([]Item, len(m.allItems))
+	for i, item := range m.allItems {
+		item.Pinned = isPinned[item.TestFile.Path]
+		item.Hidden = isHidden[item.TestFile.Path]
+		items[i] = item
+	}
+	m.allItems = items
+	m.applySort()
+	return m
+}
+
+// togglePin pins the file under the cursor to the top of the list, or
+// unpins it, and remembers the project's pins.
+func (m *Model) togglePin() {
+	i := m.cursorItem()
+	if i < 0 {
+		return
+	}
+	path := m.filteredItems[i].TestFile.Path
+	pinned := !m.filteredItems[i].Pinned
+	m.updateItem(path, func(item *Item) { item.Pinned = pinned })
+	_ = config.SaveProjectPinned(m.projectDir, m.flaggedFiles(func(item Item) bool { return item.Pinned }))
+	m.applySort()
+
+	if pinned {
+		m.notice = "Pinned " + path
+	} else {
+		m.notice = "Unpinned " + path
+	}
+}
+
+// toggleHide hides the file under the cursor from the list, or shows it
+// again when @hidden brought it back, and remembers the project's hidden
+// files.
+func (m *Model) toggleHide() {
+	i := m.cursorItem()
+	if i < 0 {
+		return
+	}
+	path := m.filteredItems[i].TestFile.Path
+	hidden := !m.filteredItems[i].Hidden
+	m.updateItem(path, func(item *Item) { item.Hidden = hidden })
+	_ = config.SaveProjectHidden(m.projectDir, m.flaggedFiles(func(item Item) bool { return item.Hidden }))
+	m.updateFilter()
+
+	if hidden {
+		m.notice = "Hid " + path + " (search @hidden to find it)"
+	} else {
+		m.notice = "Unhid " + path
+	}
+}
+
+// updateItem applies fn to the file at path in both the full and the
+// filtered list.
+func (m *Model) updateItem(path string, fn func(*Item)) {
+	for i := range m.allItems {
+		if m.allItems[i].TestFile.Path == path {
+			fn(&m.allItems[i])
+		}
+	}
+	for i := range m.filteredItems {
+		if m.filteredItems[i].TestFile.Path == path {
+			fn(&m.filteredItems[i])
+		}
+	}
+}
+
+// flaggedFiles returns the paths of the files for which flagged returns
+// true, sorted so saved lists don't depend on the list order.
+func (m Model) flaggedFiles(flagged func(Item) bool) []string {
+	var files []string
+	for _, item := range m.allItems {
+		if flagged(item) {
+			files = append(files, item.TestFile.Path)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
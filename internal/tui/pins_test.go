@@ -0,0 +1,95 @@
+package tui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/samrobinsonsauce/eztest/internal/config"
+)
+
+func testModelForPins(t *testing.T) Model {
+	return newTestModel(t, []string{
+		"test/features/checkout_test.exs",
+		"test/my_app/accounts_test.exs",
+		"test/my_app/billing_test.exs",
+		"test/my_app/cart_test.exs",
+	}).WithPins([]string{"test/my_app/cart_test.exs"}, []string{"test/features/checkout_test.exs"})
+}
+
+func TestPinnedFilesSortFirstAndHiddenFilesAreLeftOut(t *testing.T) {
+	m := testModelForPins(t)
+	want := []string{"test/my_app/cart_test.exs", "test/my_app/accounts_test.exs", "test/my_app/billing_test.exs"}
+	if got := filteredPaths(m); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected the pinned file first and the hidden one left out, got %v", got)
+	}
+	if view := m.View(); !strings.Contains(view, "cart_test.exs 📌") || !strings.Contains(view, "1 hidden") {
+		t.Fatalf("expected a pin marker and a hidden count in the view:\n%s", view)
+	}
+
+	m.searchInput.SetValue("_test")
+	m.updateFilter()
+	if got := filteredPaths(m); got[0] != "test/my_app/cart_test.exs" || len(got) != 3 {
+		t.Fatalf("expected the pinned file to stay first while searching, got %v", got)
+	}
+
+	m.searchInput.SetValue("")
+	m.updateFilter()
+	m.handleAction(actionSelectAll)
+	if got := m.getSelectedFiles(); len(got) != 3 {
+		t.Fatalf("expected select all to skip the hidden file, got %v", got)
+	}
+
+	m.searchInput.SetValue("@hidden")
+	m.updateFilter()
+	if got := filteredPaths(m); !reflect.DeepEqual(got, []string{"test/features/checkout_test.exs"}) {
+		t.Fatalf("expected @hidden to show hidden files, got %v", got)
+	}
+}
+
+func TestPinnedFilesComeFirstInTheTree(t *testing.T) {
+	m := testModelForPins(t).WithSort(SortPath, nil)
+	m.toggleView()
+	want := []string{"test/my_app/", "cart_test.exs", "accounts_test.exs", "billing_test.exs"}
+	if got := treeLabels(m.treeRows); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected the pinned file first in its directory, got %v", got)
+	}
+}
+
+func TestPinAndHideActionsArePersisted(t *testing.T) {
+	m := testModelForPins(t)
+
+	m.cursor = 2 // billing_test.exs
+	updated, _ := m.Update(altKey('f'))
+	m = updated.(Model)
+	if got := filteredPaths(m)[:2]; !reflect.DeepEqual(got, []string{"test/my_app/billing_test.exs", "test/my_app/cart_test.exs"}) {
+		t.Fatalf("expected the newly pinned file to move up, got %v", got)
+	}
+	if m.cursor != 0 || m.notice != "Pinned test/my_app/billing_test.exs" {
+		t.Fatalf("expected the cursor to follow the pinned file, got cursor %d notice %q", m.cursor, m.notice)
+	}
+
+	m.cursor = 2 // accounts_test.exs
+	updated, _ = m.Update(altKey('x'))
+	m = updated.(Model)
+	if got := filteredPaths(m); len(got) != 2 {
+		t.Fatalf("expected the hidden file to leave the list, got %v", got)
+	}
+
+	pinned, _, _ := config.GetProjectPinned(m.projectDir)
+	hidden, _, _ := config.GetProjectHidden(m.projectDir)
+	if !reflect.DeepEqual(pinned, []string{"test/my_app/billing_test.exs", "test/my_app/cart_test.exs"}) ||
+		!reflect.DeepEqual(hidden, []string{"test/features/checkout_test.exs", "test/my_app/accounts_test.exs"}) {
+		t.Fatalf("unexpected saved lists: pinned %v hidden %v", pinned, hidden)
+	}
+
+	m.searchInput.SetValue("@hidden accounts")
+	m.updateFilter()
+	m.cursor = 0
+	m.toggleHide()
+	m.searchInput.SetValue("")
+	m.updateFilter()
+	if got := filteredPaths(m); len(got) != 3 {
+		t.Fatalf("expected unhiding to bring the file back, got %v", got)
+	}
+}
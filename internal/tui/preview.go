@@ -0,0 +1,197 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/samrobinsonsauce/eztest/internal/testfile"
+)
+
+const (
+	// previewAutoWidth is the terminal width from which the preview is shown
+	// without being toggled on.
+	previewAutoWidth = 120
+	previewMinWidth  = 60
+	previewMaxLines  = 2000
+)
+
+type previewMode int
+
+const (
+	previewAuto previewMode = iota
+	previewOn
+	previewOff
+)
+
+// filePreview is a test file read for the preview pane, cached by path.
+type filePreview struct {
+	source  []string
+	outline []testfile.OutlineEntry
+	err     error
+}
+
+// WithFailureMessages supplies the last recorded failure output per file
+// for the preview pane.
+func (m Model) WithFailureMessages(messages map[string]string) Model {
+	m.failureMessages = messages
+	return m
+}
+
+func (m Model) showPreview() bool {
+	if m.rowCount() == 0 || m.width < previewMinWidth {
+		return false
+	}
+	switch m.previewMode {
+	case previewOn:
+		return true
+	case previewOff:
+		return false
+	}
+	return m.width >= previewAutoWidth
+}
+
+func (m *Model) togglePreview() {
+	if m.showPreview() {
+		m.previewMode = previewOff
+	} else {
+		m.previewMode = previewOn
+	}
+}
+
+// previewKey identifies what the preview shows so the scroll position resets
+// when the cursor moves to another file or directory.
+func (m Model) previewKey() string {
+	if m.treeView && m.cursor < len(m.treeRows) && m.treeRows[m.cursor].isDir() {
+		return "dir:" + m.treeRows[m.cursor].dir
+	}
+	if i := m.cursorItem(); i >= 0 {
+		return m.filteredItems[i].TestFile.Path
+	}
+	return ""
+}
+
+func (m *Model) scrollPreview(delta int) {
+	key := m.previewKey()
+	if key != m.previewPath {
+		m.previewPath, m.previewScroll = key, 0
+	}
+	m.previewScroll = min(max(m.previewScroll+delta, 0), max(m.previewLineCount()-1, 0))
+}
+
+func (m Model) previewLineCount() int {
+	lines, _ := m.previewLines()
+	return len(lines)
+}
+
+// previewLines renders the preview content for the cursor row: the file's
+// last failure, its outline and its highlighted source, or the files of a
+// directory row. fileLines holds the line of the file each outline and
+// source line refers to, and 0 for the others.
+func (m Model) previewLines() (lines []string, fileLines []int) {
+	if m.treeView && m.cursor < len(m.treeRows) && m.treeRows[m.cursor].isDir() {
+		row := m.treeRows[m.cursor]
+		lines := []string{previewHeadingStyle.Render(fmt.Sprintf("%d test files", len(row.items))), ""}
+		for _, i := range row.items {
+			lines = append(lines, syntaxPlainStyle.Render(m.filteredItems[i].TestFile.Path))
+		}
+		return lines, make([]int, len(lines))
+	}
+
+	i := m.cursorItem()
+	if i < 0 {
+		return nil, nil
+	}
+	tf := m.filteredItems[i].TestFile
+	preview := m.loadPreview(tf)
+
+	if msg := m.failureMessages[tf.Path]; msg != "" {
+		lines = append(lines, errorStyle.Render("Last failure"))
+		for _, line := range strings.Split(msg, "\n") {
+			lines = append(lines, syntaxPlainStyle.Render(line))
+		}
+		lines = append(lines, "")
+	}
+	if preview.err != nil {
+		lines = append(lines, errorStyle.Render("Cannot read file: "+preview.err.Error()))
+		return lines, make([]int, len(lines))
+	}
+
+	if len(preview.outline) > 0 {
+		lines = append(lines, previewHeadingStyle.Render("Outline"))
+		fileLines = make([]int, len(lines))
+		for _, entry := range preview.outline {
+			lines = append(lines, outlineLine(entry))
+			fileLines = append(fileLines, entry.Line)
+		}
+		lines = append(lines, "")
+	}
+
+	lines = append(lines, previewHeadingStyle.Render("Source"))
+	fileLines = append(fileLines, make([]int, len(lines)-len(fileLines))...)
+	for n, line := range preview.source {
+		lines = append(lines, previewLineNumberStyle.Render(fmt.Sprintf("%4d ", n+1))+line)
+		fileLines = append(fileLines, n+1)
+	}
+	return lines, fileLines
+}
+
+func outlineLine(entry testfile.OutlineEntry) string {
+	var text string
+	switch entry.Kind {
+	case testfile.OutlineModule:
+		text = syntaxKeywordStyle.Render("defmodule ") + syntaxModuleStyle.Render(entry.Name)
+	case testfile.OutlineDescribe:
+		text = syntaxKeywordStyle.Render("describe ") + syntaxStringStyle.Render(fmt.Sprintf("%q", entry.Name))
+	default:
+		text = syntaxKeywordStyle.Render("test ") + syntaxStringStyle.Render(fmt.Sprintf("%q", entry.Name))
+		if entry.Describe != "" {
+			text = "  " + text
+		}
+	}
+	return text + previewLineNumberStyle.Render(fmt.Sprintf("  :%d", entry.Line))
+}
+
+// loadPreview reads and highlights a test file once per session.
+func (m Model) loadPreview(tf testfile.TestFile) *filePreview {
+	if cached, ok := m.previews[tf.Path]; ok {
+		return cached
+	}
+
+	path := tf.AbsolutePath
+	if path == "" {
+		path = filepath.Join(m.projectDir, tf.Path)
+	}
+	preview := &filePreview{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		preview.err = err
+	} else {
+		src := strings.ReplaceAll(string(data), "\r\n", "\n")
+		preview.outline, _ = testfile.ParseOutline(strings.NewReader(src))
+		lines := strings.Split(strings.TrimRight(src, "\n"), "\n")
+		if len(lines) > previewMaxLines {
+			lines = lines[:previewMaxLines]
+		}
+		preview.source = highlightElixir(lines)
+	}
+	m.previews[tf.Path] = preview
+	return preview
+}
+
+// renderPreview draws the preview box at the given outer size.
+func (m Model) renderPreview(width, height int) string {
+	lines, _ := m.previewLines()
+	if m.previewPath == m.previewKey() && m.previewScroll < len(lines) {
+		lines = lines[m.previewScroll:]
+	}
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+
+	contentWidth := max(width-2, 1)
+	content := lipgloss.NewStyle().MaxWidth(contentWidth).Render(strings.Join(lines, "\n"))
+	return previewStyle.Width(width).Height(height).Render(content)
+}
@@ -0,0 +1,118 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+const previewSource = `defmodule MyApp.AuthTest do
+  use ExUnit.Case, async: true
+
+  describe "verify/1" do
+    test "rejects an expired token" do
+      assert :error == MyApp.Auth.verify("old")
+    end
+  end
+end
+`
+
+func testModelForPreview(t *testing.T) Model {
+	t.Helper()
+	m := newTestModel(t, []string{"test/auth_test.exs", "test/missing_test.exs"}, withFailed("test/auth_test.exs")).
+		WithFailureMessages(map[string]string{"test/auth_test.exs": "1) test rejects an expired token (MyApp.AuthTest)"})
+	m.width, m.height = 160, 40
+
+	path := filepath.Join(m.projectDir, "test", "auth_test.exs")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(previewSource), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestPreviewShowsFailureOutlineAndSource(t *testing.T) {
+	m := testModelForPreview(t)
+	if !m.showPreview() {
+		t.Fatal("expected the preview to show automatically on a wide terminal")
+	}
+
+	view := m.View()
+	for _, want := range []string{"Last failure", "1) test rejects an expired token", "Outline", `test "rejects an expired token"  :5`, "Source", "   6       assert :error"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in preview, got:\n%s", want, view)
+		}
+	}
+
+	m.cursor = 1
+	if view := m.View(); !strings.Contains(view, "Cannot read file") {
+		t.Errorf("expected an error for an unreadable file, got:\n%s", view)
+	}
+}
+
+func TestPreviewToggleAndScroll(t *testing.T) {
+	m := testModelForPreview(t)
+
+	m.scrollPreview(3)
+	if m.previewScroll != 3 || m.previewPath != "test/auth_test.exs" {
+		t.Fatalf("unexpected scroll state %d %q", m.previewScroll, m.previewPath)
+	}
+	m.scrollPreview(-10)
+	if m.previewScroll != 0 {
+		t.Fatalf("expected scroll to stop at the top, got %d", m.previewScroll)
+	}
+	m.scrollPreview(1000)
+	if got, want := m.previewScroll, m.previewLineCount()-1; got != want {
+		t.Fatalf("expected scroll to stop at the last line %d, got %d", want, got)
+	}
+
+	m.cursor = 1
+	m.scrollPreview(0)
+	if m.previewScroll != 0 || m.previewPath != "test/missing_test.exs" {
+		t.Fatalf("expected scroll to reset for a new file, got %d %q", m.previewScroll, m.previewPath)
+	}
+
+	m.togglePreview()
+	if m.showPreview() {
+		t.Fatal("expected toggling to hide the preview")
+	}
+	m.width = 80
+	m.togglePreview()
+	if !m.showPreview() {
+		t.Fatal("expected toggling to show the preview on a narrow terminal")
+	}
+}
+
+func TestHighlightElixirKeepsTextAndStylesTokens(t *testing.T) {
+	lipgloss.SetColorProfile(termenv.ANSI256)
+	t.Cleanup(func() { lipgloss.SetColorProfile(termenv.Ascii) })
+	ApplyTheme("default")
+
+	lines := strings.Split(strings.TrimRight(previewSource, "\n")+"\n  @moduledoc \"\"\"\n  # not a comment\n  \"\"\" # comment", "\n")
+	highlighted := highlightElixir(lines)
+	for i, line := range highlighted {
+		if got := ansiEscapePattern.ReplaceAllString(line, ""); got != lines[i] {
+			t.Fatalf("line %d changed: got %q want %q", i+1, got, lines[i])
+		}
+	}
+
+	for _, want := range []string{
+		syntaxKeywordStyle.Render("defmodule"),
+		syntaxModuleStyle.Render("MyApp"),
+		syntaxAtomStyle.Render("async:"),
+		syntaxAtomStyle.Render(":error"),
+		syntaxStringStyle.Render(`"rejects an expired token"`),
+		syntaxStringStyle.Render("  # not a comment"),
+		syntaxCommentStyle.Render("# comment"),
+	} {
+		if !strings.Contains(strings.Join(highlighted, "\n"), want) {
+			t.Errorf("expected %q in highlighted source", want)
+		}
+	}
+}
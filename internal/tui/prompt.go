@@ -0,0 +1,67 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type promptKind int
+
+const (
+	promptNone promptKind = iota
+	promptSaveSet
+	promptSelectGlob
+)
+
+func newPromptInput(label string) textinput.Model {
+	ti := textinput.New()
+	ti.Prompt = label
+	ti.PromptStyle = searchPromptStyle
+	ti.TextStyle = searchInputStyle
+	ti.CharLimit = 128
+	ti.Width = 40
+	ti.Focus()
+	return ti
+}
+
+func (m *Model) openPrompt(kind promptKind, label string) tea.Cmd {
+	m.promptKind = kind
+	m.prompt = newPromptInput(label)
+	return textinput.Blink
+}
+
+// updatePrompt handles key input while a prompt is open. Enter submits the
+// value, Esc cancels, everything else edits the prompt.
+func (m Model) updatePrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc, tea.KeyCtrlC:
+		m.promptKind = promptNone
+		m.notice = "Cancelled"
+		return m, nil
+	case tea.KeyEnter:
+		kind := m.promptKind
+		value := strings.TrimSpace(m.prompt.Value())
+		m.promptKind = promptNone
+		if value == "" {
+			m.notice = "Cancelled"
+			return m, nil
+		}
+		m.submitPrompt(kind, value)
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.prompt, cmd = m.prompt.Update(msg)
+	return m, cmd
+}
+
+func (m *Model) submitPrompt(kind promptKind, value string) {
+	switch kind {
+	case promptSaveSet:
+		m.saveSet(value)
+	case promptSelectGlob:
+		m.selectGlob(value)
+	}
+}
@@ -0,0 +1,82 @@
+package tui
+
+import (
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// sequenceTimeout is how long a partly typed key sequence waits for its
+// next key before the keys are handled one at a time.
+const sequenceTimeout = time.Second
+
+type sequenceTimeoutMsg struct {
+	id int
+}
+
+// updateSequence feeds a keypress to the key map's sequences. It reports
+// whether the key was consumed, either completing a sequence or extending a
+// pending one. Sequences that start with a printable key only apply in
+// normal mode.
+func (m *Model) updateSequence(msg tea.KeyMsg) (bool, tea.Cmd) {
+	if m.replaying || len(m.keyMap.sequences) == 0 {
+		return false, nil
+	}
+	if len(m.pendingKeys) > 0 && msg.Type == tea.KeyEsc {
+		m.pendingKeys = nil
+		return true, nil
+	}
+
+	keys := make([]string, 0, len(m.pendingKeys)+1)
+	for _, k := range m.pendingKeys {
+		keys = append(keys, sequenceKeyName(k))
+	}
+	keys = append(keys, sequenceKeyName(msg))
+
+	typing := !m.modal || m.mode != modeNormal
+	action, prefix := m.keyMap.matchSequence(keys, typing)
+	switch {
+	case action != "":
+		m.pendingKeys = nil
+		return true, m.handleAction(action)
+	case prefix:
+		m.pendingKeys = append(m.pendingKeys, msg)
+		m.sequenceID++
+		id := m.sequenceID
+		return true, tea.Tick(sequenceTimeout, func(time.Time) tea.Msg {
+			return sequenceTimeoutMsg{id: id}
+		})
+	}
+	return false, nil
+}
+
+// flushSequence handles the keys of an abandoned sequence one at a time, as
+// if no sequence had started with them.
+func (m Model) flushSequence() (Model, tea.Cmd) {
+	pending := m.pendingKeys
+	m.pendingKeys = nil
+	if len(pending) == 0 {
+		return m, nil
+	}
+
+	var cmds []tea.Cmd
+	m.replaying = true
+	for _, k := range pending {
+		updated, cmd := m.Update(k)
+		m = updated.(Model)
+		cmds = append(cmds, cmd)
+	}
+	m.replaying = false
+	return m, tea.Batch(cmds...)
+}
+
+// pendingHelp is the help line while a sequence is pending: the keys typed
+// so far and the ways it can complete.
+func (m Model) pendingHelp() string {
+	keys := make([]string, len(m.pendingKeys))
+	for i, k := range m.pendingKeys {
+		keys[i] = sequenceKeyName(k)
+	}
+	return formatHelpKey(strings.Join(keys, " ")) + " … " + m.keyMap.sequenceHelp(keys) + " • esc: cancel"
+}
@@ -0,0 +1,91 @@
+package tui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func testModelForSequences(t *testing.T, modal bool) Model {
+	keyMap := NewKeyMap(map[string][]string{
+		"select_all":   []string{"ctrl+x a", "g a"},
+		"deselect_all": []string{"ctrl+x d"},
+	})
+	return newTestModel(t, []string{"test/a_test.exs", "test/b_test.exs", "test/c_test.exs"}, withKeyMap(keyMap)).
+		WithModal(modal)
+}
+
+func sendKey(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
+	updated, cmd := m.Update(msg)
+	return updated.(Model), cmd
+}
+
+func TestSequenceRunsActionAndShowsPendingKeys(t *testing.T) {
+	m := testModelForSequences(t, false)
+
+	m, cmd := sendKey(m, tea.KeyMsg{Type: tea.KeyCtrlX})
+	if cmd == nil || len(m.pendingKeys) != 1 {
+		t.Fatal("expected ctrl+x to start a sequence with a timeout")
+	}
+	if view := m.View(); !strings.Contains(view, "^x … d: deselect all • a: select all") {
+		t.Fatalf("expected the help line to list the continuations, got %q", view)
+	}
+
+	m, _ = sendKey(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")})
+	if got := len(m.getSelectedFiles()); got != 3 || len(m.pendingKeys) != 0 {
+		t.Fatalf("expected the sequence to select all files, got %d selected", got)
+	}
+	if m.searchInput.Value() != "" {
+		t.Fatalf("sequence keys must not reach the filter, got %q", m.searchInput.Value())
+	}
+
+	// Printable sequences only apply in normal mode, so typing still filters.
+	m, _ = sendKey(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("g")})
+	if m.searchInput.Value() != "g" || len(m.pendingKeys) != 0 {
+		t.Fatalf("expected g to be typed into the filter, got %q", m.searchInput.Value())
+	}
+}
+
+func TestSequenceCancelAndTimeout(t *testing.T) {
+	m := testModelForSequences(t, false)
+
+	m, _ = sendKey(m, tea.KeyMsg{Type: tea.KeyCtrlX})
+	m, _ = sendKey(m, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.IsQuitting() || len(m.pendingKeys) != 0 {
+		t.Fatal("expected esc to cancel the pending sequence without quitting")
+	}
+
+	m, _ = sendKey(m, tea.KeyMsg{Type: tea.KeyCtrlX})
+	stale := m.sequenceID
+	m, _ = sendKey(m, tea.KeyMsg{Type: tea.KeyEsc})
+	m, _ = sendKey(m, tea.KeyMsg{Type: tea.KeyCtrlX})
+	updated, _ := m.Update(sequenceTimeoutMsg{id: stale})
+	if m = updated.(Model); len(m.pendingKeys) != 1 {
+		t.Fatal("expected a stale timeout to leave the new sequence pending")
+	}
+	updated, _ = m.Update(sequenceTimeoutMsg{id: m.sequenceID})
+	if m = updated.(Model); len(m.pendingKeys) != 0 {
+		t.Fatal("expected the timeout to abandon the sequence")
+	}
+}
+
+func TestSequenceInNormalModeFallsBackToSingleKeys(t *testing.T) {
+	m := testModelForSequences(t, true)
+
+	m = pressKeys(m, "j", "j", "g", "a")
+	if got := m.getSelectedFiles(); !reflect.DeepEqual(got, []string{"test/a_test.exs", "test/b_test.exs", "test/c_test.exs"}) {
+		t.Fatalf("expected g a to select all, got %v", got)
+	}
+
+	m = pressKeys(m, "g", "g")
+	if m.cursor != 0 || len(m.pendingKeys) != 0 {
+		t.Fatalf("expected g g to fall back to jumping to the top, got cursor %d", m.cursor)
+	}
+
+	m = pressKeys(m, "g", "x")
+	if got := m.getSelectedFiles(); !reflect.DeepEqual(got, []string{"test/b_test.exs", "test/c_test.exs"}) {
+		t.Fatalf("expected x after an abandoned g to toggle the cursor file, got %v", got)
+	}
+}
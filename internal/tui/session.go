@@ -0,0 +1,104 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/samrobinsonsauce/eztest/internal/config"
+)
+
+// WithSession restores the search query and the file or directory under
+// the cursor from the last session. It goes after the builders that decide
+// the order of the list.
+func (m Model) WithSession(query, cursor string) Model {
+	if query != "" {
+		m.searchInput.SetValue(query)
+		m.searchInput.CursorEnd()
+		m.updateFilter()
+	}
+	for row := 0; row < m.rowCount(); row++ {
+		if m.rowKey(row) == cursor {
+			m.cursor = row
+			break
+		}
+	}
+	return m
+}
+
+// WithSearchHistory sets the past queries that can be recalled in the
+// search box, oldest first.
+func (m Model) WithSearchHistory(history []string) Model {
+	m.searchHistory = append([]string(nil), history...)
+	m.historyIndex = len(m.searchHistory)
+	return m
+}
+
+// rowKey identifies a row across sessions: a file's path, or a tree
+// directory's path.
+func (m Model) rowKey(row int) string {
+	if m.treeView {
+		if row >= len(m.treeRows) {
+			return ""
+		}
+		if m.treeRows[row].isDir() {
+			return m.treeRows[row].dir
+		}
+	}
+	if i := m.rowItem(row); i >= 0 && i < len(m.filteredItems) {
+		return m.filteredItems[i].TestFile.Path
+	}
+	return ""
+}
+
+// saveSession remembers the query and cursor for the next start, along with
+// the search history. The view and sort mode are saved when they change.
+func (m *Model) saveSession() {
+	m.rememberQuery()
+	_ = config.SaveProjectSearchHistory(m.projectDir, m.searchHistory)
+	_ = config.SaveProjectSession(m.projectDir, config.Session{
+		Query:  m.searchInput.Value(),
+		Cursor: m.rowKey(m.cursor),
+	})
+}
+
+// rememberQuery adds the current query to the search history, moving it to
+// the end if it was already there.
+func (m *Model) rememberQuery() {
+	query := strings.TrimSpace(m.searchInput.Value())
+	if query == "" {
+		return
+	}
+	history := make([]string, 0, len(m.searchHistory)+1)
+	for _, q := range m.searchHistory {
+		if q != query {
+			history = append(history, q)
+		}
+	}
+	m.searchHistory = append(history, query)
+	m.historyIndex = len(m.searchHistory)
+}
+
+// recallHistory replaces the query with an older (step -1) or newer (step
+// 1) one from the history. Stepping past the newest entry brings back what
+// was typed before recalling.
+func (m *Model) recallHistory(step int) {
+	if len(m.searchHistory) == 0 {
+		m.notice = "No search history"
+		return
+	}
+	next := m.historyIndex + step
+	if next < 0 || next > len(m.searchHistory) {
+		return
+	}
+	if m.historyIndex == len(m.searchHistory) {
+		m.historyDraft = m.searchInput.Value()
+	}
+	m.historyIndex = next
+
+	query := m.historyDraft
+	if next < len(m.searchHistory) {
+		query = m.searchHistory[next]
+	}
+	m.searchInput.SetValue(query)
+	m.searchInput.CursorEnd()
+	m.updateFilter()
+}
@@ -0,0 +1,85 @@
+package tui
+
+import (
+	"reflect"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/samrobinsonsauce/eztest/internal/config"
+)
+
+func TestSessionIsSavedOnQuitAndRestored(t *testing.T) {
+	m := testModelForTree(t).WithSort(SortPath, nil)
+	m.toggleView()
+	m.cycleSort()
+	m.searchInput.SetValue("dir:test/my_app")
+	m.updateFilter()
+	m.cursor = 1 // accounts/
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	if !updated.(Model).IsQuitting() {
+		t.Fatal("expected ctrl+c to quit")
+	}
+
+	session, err := config.GetProjectSession(m.projectDir)
+	if err != nil {
+		t.Fatalf("GetProjectSession returned error: %v", err)
+	}
+	want := config.Session{Query: "dir:test/my_app", Cursor: "test/my_app/accounts"}
+	if !reflect.DeepEqual(session, want) {
+		t.Fatalf("unexpected session: got %+v want %+v", session, want)
+	}
+	if history, _ := config.GetProjectSearchHistory(m.projectDir); !reflect.DeepEqual(history, []string{"dir:test/my_app"}) {
+		t.Fatalf("expected the query in the search history, got %v", history)
+	}
+
+	treeView, collapsed, _ := config.GetProjectView(m.projectDir)
+	sortMode, _ := config.GetProjectSort(m.projectDir)
+	if !treeView || sortMode != SortFailures {
+		t.Fatalf("expected the view and sort to be saved, got tree %v sort %q", treeView, sortMode)
+	}
+
+	restored := testModelForTree(t).
+		WithView(treeView, collapsed).
+		WithSort(sortMode, nil).
+		WithSession(session.Query, session.Cursor)
+	if restored.searchInput.Value() != "dir:test/my_app" || !restored.treeView || restored.cursor != 1 {
+		t.Fatalf("expected the session to be restored, got query %q tree %v cursor %d",
+			restored.searchInput.Value(), restored.treeView, restored.cursor)
+	}
+}
+
+func TestSearchHistoryRecall(t *testing.T) {
+	m := testModelForTree(t).WithSearchHistory([]string{"billing", "accounts"})
+	m.searchInput.SetValue("us")
+
+	recall := func(r rune, want string) {
+		t.Helper()
+		updated, _ := m.Update(altKey(r))
+		m = updated.(Model)
+		if got := m.searchInput.Value(); got != want {
+			t.Fatalf("expected the query %q, got %q", want, got)
+		}
+	}
+	recall('p', "accounts")
+	if len(m.filteredItems) != 2 {
+		t.Fatalf("expected a recalled query to filter the list, got %d items", len(m.filteredItems))
+	}
+	recall('p', "billing")
+	recall('p', "billing")
+	recall('n', "accounts")
+	recall('n', "us")
+
+	m.searchInput.SetValue("team")
+	m.updateFilter()
+	m.changeSelection("toggle", m.toggleCursorSelection)
+	if want := []string{"billing", "accounts"}; !reflect.DeepEqual(m.searchHistory, want) {
+		t.Fatalf("expected a selection not to touch the history, got %v", m.searchHistory)
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	m = updated.(Model)
+	if want := []string{"billing", "accounts", "team"}; !reflect.DeepEqual(m.searchHistory, want) {
+		t.Fatalf("expected the query left on quit to join the history, got %v", m.searchHistory)
+	}
+}
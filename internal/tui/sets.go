@@ -0,0 +1,134 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/samrobinsonsauce/eztest/internal/config"
+)
+
+// NamedSet is a named selection of test files that can be switched to from
+// the TUI. Origin describes where it was defined ("saved" or "config").
+type NamedSet struct {
+	Name   string
+	Files  []string
+	Origin string
+}
+
+// WithSets makes the given named sets available for switching.
+func (m Model) WithSets(sets []NamedSet) Model {
+	m.sets = append([]NamedSet(nil), sets...)
+	sortSets(m.sets)
+	m.setIndex = -1
+	return m
+}
+
+func sortSets(sets []NamedSet) {
+	sort.Slice(sets, func(i, j int) bool {
+		return sets[i].Name < sets[j].Name
+	})
+}
+
+// ActiveSet returns the name of the set currently applied, if any.
+func (m Model) ActiveSet() string {
+	if m.setIndex < 0 || m.setIndex >= len(m.sets) {
+		return ""
+	}
+	return m.sets[m.setIndex].Name
+}
+
+// setIndexOf returns the position of the set called name, or -1.
+func (m Model) setIndexOf(name string) int {
+	for i, set := range m.sets {
+		if name != "" && set.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// cycleSet replaces the selection with the next named set. After the last
+// set it returns to the selection that was active before switching.
+func (m *Model) cycleSet() {
+	if len(m.sets) == 0 {
+		m.notice = "No named sets. Save one with " + m.keyMap.SaveSet.Help().Key
+		return
+	}
+
+	if m.setIndex+1 >= len(m.sets) {
+		m.setIndex = -1
+		m.applySelection(m.baseSelection)
+		m.notice = fmt.Sprintf("Restored previous selection (%d files)", len(m.baseSelection))
+		return
+	}
+	m.useSet(m.setIndex + 1)
+}
+
+// useSet replaces the selection with the set at index i, remembering the
+// selection it replaces the first time.
+func (m *Model) useSet(i int) {
+	if m.setIndex < 0 {
+		m.baseSelection = m.getSelectedFiles()
+	}
+	m.setIndex = i
+	set := m.sets[i]
+	m.applySelection(set.Files)
+	m.notice = fmt.Sprintf("Set %s: %d files", set.Name, m.selectedCount())
+}
+
+func (m *Model) saveSet(name string) {
+	files := m.getSelectedFiles()
+	if len(files) == 0 {
+		m.notice = "Nothing selected to save"
+		return
+	}
+
+	if err := config.SaveProjectSet(m.projectDir, name, files); err != nil {
+		m.notice = "Could not save set: " + err.Error()
+		return
+	}
+
+	if m.setIndex < 0 {
+		m.baseSelection = files
+	}
+
+	replaced := false
+	for i := range m.sets {
+		if m.sets[i].Name == name {
+			m.sets[i] = NamedSet{Name: name, Files: files, Origin: "saved"}
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		m.sets = append(m.sets, NamedSet{Name: name, Files: files, Origin: "saved"})
+		sortSets(m.sets)
+	}
+	m.setIndex = m.setIndexOf(name)
+
+	m.notice = fmt.Sprintf("Saved set %s (%d files)", name, len(files))
+}
+
+// applySelection replaces the current selection with paths.
+func (m *Model) applySelection(paths []string) {
+	wanted := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		wanted[p] = true
+	}
+	for i := range m.allItems {
+		m.allItems[i].Selected = wanted[m.allItems[i].TestFile.Path]
+	}
+	for i := range m.filteredItems {
+		m.filteredItems[i].Selected = wanted[m.filteredItems[i].TestFile.Path]
+	}
+}
+
+func (m *Model) selectedCount() int {
+	count := 0
+	for _, item := range m.allItems {
+		if item.Selected {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,53 @@
+package tui
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/samrobinsonsauce/eztest/internal/config"
+)
+
+func TestCycleSetAppliesSetsAndRestoresSelection(t *testing.T) {
+	m := testModelForFailures(t).WithSets([]NamedSet{
+		{Name: "smoke", Files: []string{"test/api_test.exs"}},
+		{Name: "auth", Files: []string{"test/auth_test.exs", "test/user_test.exs"}},
+	})
+
+	m.cycleSet()
+	if got := m.ActiveSet(); got != "auth" {
+		t.Fatalf("expected sets to cycle alphabetically, got %q", got)
+	}
+	if got, want := m.getSelectedFiles(), []string{"test/user_test.exs", "test/auth_test.exs"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected selection for auth set: got %v want %v", got, want)
+	}
+
+	m.cycleSet()
+	if got, want := m.getSelectedFiles(), []string{"test/api_test.exs"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected selection for smoke set: got %v want %v", got, want)
+	}
+
+	m.cycleSet()
+	if got := m.ActiveSet(); got != "" {
+		t.Fatalf("expected to return to the original selection, active set %q", got)
+	}
+	if got, want := m.getSelectedFiles(), []string{"test/user_test.exs"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected original selection to be restored: got %v want %v", got, want)
+	}
+}
+
+func TestSaveSetPersistsSelection(t *testing.T) {
+	m := testModelForFailures(t)
+	m.saveSet("mine")
+
+	if got := m.ActiveSet(); got != "mine" {
+		t.Fatalf("expected saved set to become active, got %q", got)
+	}
+
+	sets, err := config.GetProjectSets(m.projectDir)
+	if err != nil {
+		t.Fatalf("GetProjectSets returned error: %v", err)
+	}
+	if got, want := sets["mine"], []string{"test/user_test.exs"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected saved set: got %v want %v", got, want)
+	}
+}
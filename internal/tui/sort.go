@@ -0,0 +1,207 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/samrobinsonsauce/eztest/internal/config"
+	"github.com/samrobinsonsauce/eztest/internal/search"
+)
+
+// Sort modes, in the order the sort action cycles through them. The names
+// are what is stored in project state.
+const (
+	SortPath     = "path"
+	SortFailures = "failures"
+	SortRecent   = "recent"
+	SortSlowest  = "slowest"
+	SortFastest  = "fastest"
+	SortFrecency = "frecency"
+)
+
+var sortModes = []string{SortPath, SortFailures, SortRecent, SortSlowest, SortFastest, SortFrecency}
+
+var sortLabels = map[string]string{
+	SortPath:     "path",
+	SortFailures: "failures first",
+	SortRecent:   "recently modified",
+	SortSlowest:  "slowest",
+	SortFastest:  "fastest",
+	SortFrecency: "frecency",
+}
+
+// WithSort orders the list by mode, using history for the duration and
+// frecency modes. Unknown modes sort by path.
+func (m Model) WithSort(mode string, history []config.RunRecord) Model {
+	m.durations = fileDurations(history)
+	m.frecency = fileFrecency(history, time.Now())
+	if _, ok := sortLabels[mode]; !ok {
+		mode = SortPath
+	}
+	m.sortMode = mode
+	m.applySort()
+	return m
+}
+
+// cycleSort switches to the next sort mode and remembers it.
+func (m *Model) cycleSort() {
+	next := 0
+	for i, mode := range sortModes {
+		if mode == m.sortMode {
+			next = (i + 1) % len(sortModes)
+		}
+	}
+	m.sortMode = sortModes[next]
+	m.applySort()
+	_ = config.SaveProjectSort(m.projectDir, m.sortMode)
+	m.notice = "Sorted by " + sortLabels[m.sortMode]
+}
+
+// applySort reorders allItems by the sort mode, pinned files first, and
+// refilters, keeping the cursor on the same file.
+func (m *Model) applySort() {
+	current := ""
+	if i := m.cursorItem(); i >= 0 {
+		current = m.filteredItems[i].TestFile.Path
+	}
+
+	if m.sortMode == SortRecent && m.mtimes == nil {
+		m.mtimes = m.fileMTimes()
+	}
+	less := m.sortLess()
+	sort.SliceStable(m.allItems, func(i, j int) bool {
+		if m.allItems[i].Pinned != m.allItems[j].Pinned {
+			return m.allItems[i].Pinned
+		}
+		if less != nil {
+			if before, decided := less(m.allItems[i], m.allItems[j]); decided {
+				return before
+			}
+		}
+		return m.allItems[i].TestFile.Path < m.allItems[j].TestFile.Path
+	})
+	m.updateFilter()
+
+	for row := 0; row < m.rowCount(); row++ {
+		if item := m.rowItem(row); item >= 0 && m.filteredItems[item].TestFile.Path == current {
+			m.cursor = row
+			break
+		}
+	}
+}
+
+// sortLess compares two items by the sort mode. decided is false when the
+// mode ranks them equally and the path should decide.
+func (m Model) sortLess() func(a, b Item) (before, decided bool) {
+	switch m.sortMode {
+	case SortFailures:
+		return func(a, b Item) (bool, bool) {
+			return a.Failed, a.Failed != b.Failed
+		}
+	case SortRecent:
+		return func(a, b Item) (bool, bool) {
+			ta, tb := m.mtimes[a.TestFile.Path], m.mtimes[b.TestFile.Path]
+			return ta.After(tb), !ta.Equal(tb)
+		}
+	case SortSlowest, SortFastest:
+		slowest := m.sortMode == SortSlowest
+		return func(a, b Item) (bool, bool) {
+			da, okA := m.durations[a.TestFile.Path]
+			db, okB := m.durations[b.TestFile.Path]
+			if okA != okB {
+				// Files that never ran come last either way.
+				return okA, true
+			}
+			if slowest {
+				return da > db, da != db
+			}
+			return da < db, da != db
+		}
+	case SortFrecency:
+		return func(a, b Item) (bool, bool) {
+			fa, fb := m.frecency[a.TestFile.Path], m.frecency[b.TestFile.Path]
+			return fa > fb, fa != fb
+		}
+	}
+	return nil
+}
+
+// sortMatches keeps pinned files first and makes the sort mode the
+// tiebreaker under fuzzy relevance. In path mode the search's own
+// tiebreaker, the shorter path, is kept.
+func (m Model) sortMatches(matches []search.Match) {
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := m.allItems[matches[i].Index], m.allItems[matches[j].Index]
+		if a.Pinned != b.Pinned {
+			return a.Pinned
+		}
+		if m.sortMode == SortPath || m.sortMode == "" {
+			return false
+		}
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Index < matches[j].Index
+	})
+}
+
+func (m Model) fileMTimes() map[string]time.Time {
+	mtimes := make(map[string]time.Time, len(m.allItems))
+	for _, item := range m.allItems {
+		path := item.TestFile.AbsolutePath
+		if path == "" {
+			path = filepath.Join(m.projectDir, item.TestFile.Path)
+		}
+		if info, err := os.Stat(path); err == nil {
+			mtimes[item.TestFile.Path] = info.ModTime()
+		}
+	}
+	return mtimes
+}
+
+// fileDurations estimates how long each file takes from the run history:
+// the average of each run's duration split evenly across its files. Runs
+// of a single file give its exact time.
+func fileDurations(history []config.RunRecord) map[string]time.Duration {
+	totals := map[string]time.Duration{}
+	counts := map[string]int{}
+	for _, run := range history {
+		if len(run.Files) == 0 || run.Duration <= 0 {
+			continue
+		}
+		share := run.Duration / time.Duration(len(run.Files))
+		for _, file := range run.Files {
+			totals[file] += share
+			counts[file]++
+		}
+	}
+	durations := make(map[string]time.Duration, len(totals))
+	for file, total := range totals {
+		durations[file] = total / time.Duration(counts[file])
+	}
+	return durations
+}
+
+// fileFrecency scores how often and how recently each file was run. Each
+// run adds a weight that shrinks with its age.
+func fileFrecency(history []config.RunRecord, now time.Time) map[string]float64 {
+	scores := map[string]float64{}
+	for _, run := range history {
+		age := now.Sub(run.StartedAt)
+		weight := 0.25
+		switch {
+		case age < 24*time.Hour:
+			weight = 4
+		case age < 7*24*time.Hour:
+			weight = 2
+		case age < 30*24*time.Hour:
+			weight = 1
+		}
+		for _, file := range run.Files {
+			scores[file] += weight
+		}
+	}
+	return scores
+}
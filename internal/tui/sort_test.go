@@ -0,0 +1,70 @@
+package tui
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/samrobinsonsauce/eztest/internal/config"
+)
+
+func filteredPaths(m Model) []string {
+	paths := make([]string, len(m.filteredItems))
+	for i, item := range m.filteredItems {
+		paths[i] = item.TestFile.Path
+	}
+	return paths
+}
+
+func TestSortModes(t *testing.T) {
+	history := []config.RunRecord{
+		{StartedAt: time.Now().Add(-10 * 24 * time.Hour), Duration: 2 * time.Second, Files: []string{"test/a_test.exs", "test/b_test.exs"}},
+		{StartedAt: time.Now().Add(-time.Hour), Duration: 3 * time.Second, Files: []string{"test/c_test.exs"}},
+	}
+	m := newTestModel(t, []string{"test/c_test.exs", "test/a_test.exs", "test/b_test.exs"}, withFailed("test/b_test.exs")).
+		WithSort("unknown", history)
+
+	steps := []struct {
+		mode string
+		want []string
+	}{
+		{SortPath, []string{"test/a_test.exs", "test/b_test.exs", "test/c_test.exs"}},
+		{SortFailures, []string{"test/b_test.exs", "test/a_test.exs", "test/c_test.exs"}},
+		{SortRecent, []string{"test/a_test.exs", "test/b_test.exs", "test/c_test.exs"}},
+		{SortSlowest, []string{"test/c_test.exs", "test/a_test.exs", "test/b_test.exs"}},
+		{SortFastest, []string{"test/a_test.exs", "test/b_test.exs", "test/c_test.exs"}},
+		{SortFrecency, []string{"test/c_test.exs", "test/a_test.exs", "test/b_test.exs"}},
+		{SortPath, []string{"test/a_test.exs", "test/b_test.exs", "test/c_test.exs"}},
+	}
+	for i, step := range steps {
+		if i > 0 {
+			m.cycleSort()
+		}
+		if m.sortMode != step.mode {
+			t.Fatalf("step %d: expected mode %s, got %s", i, step.mode, m.sortMode)
+		}
+		if got := filteredPaths(m); !reflect.DeepEqual(got, step.want) {
+			t.Fatalf("%s: got %v want %v", step.mode, got, step.want)
+		}
+	}
+
+	if saved, _ := config.GetProjectSort(m.projectDir); saved != SortPath {
+		t.Fatalf("expected the mode to be saved, got %q", saved)
+	}
+}
+
+func TestSortModeBreaksSearchTies(t *testing.T) {
+	history := []config.RunRecord{
+		{StartedAt: time.Now(), Duration: time.Second, Files: []string{"test/b_test.exs"}},
+		{StartedAt: time.Now(), Duration: 5 * time.Second, Files: []string{"test/c_test.exs"}},
+	}
+	m := newTestModel(t, []string{"test/a_test.exs", "test/b_test.exs", "test/c_test.exs"}).
+		WithSort(SortSlowest, history)
+	m.searchInput.SetValue("test.exs")
+	m.updateFilter()
+
+	want := []string{"test/c_test.exs", "test/b_test.exs", "test/a_test.exs"}
+	if got := filteredPaths(m); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected equally relevant matches in sort order, got %v", got)
+	}
+}
@@ -1,6 +1,9 @@
 package tui
 
 import (
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
@@ -99,6 +102,8 @@ var (
 
 	failedMarkerStyle lipgloss.Style
 
+	pinnedMarkerStyle lipgloss.Style
+
 	cursorStyle lipgloss.Style
 
 	noCursorStyle lipgloss.Style
@@ -111,6 +116,24 @@ var (
 
 	noResultsStyle lipgloss.Style
 
+	noticeStyle lipgloss.Style
+
+	matchHighlightStyle lipgloss.Style
+
+	treeCountStyle lipgloss.Style
+
+	previewStyle           lipgloss.Style
+	previewHeadingStyle    lipgloss.Style
+	previewLineNumberStyle lipgloss.Style
+
+	syntaxPlainStyle   lipgloss.Style
+	syntaxKeywordStyle lipgloss.Style
+	syntaxStringStyle  lipgloss.Style
+	syntaxCommentStyle lipgloss.Style
+	syntaxAtomStyle    lipgloss.Style
+	syntaxModuleStyle  lipgloss.Style
+	syntaxNumberStyle  lipgloss.Style
+
 	bannerStyle    lipgloss.Style
 	logoStyle      lipgloss.Style
 	fileCountStyle lipgloss.Style
@@ -123,12 +146,82 @@ func init() {
 }
 
 func ApplyTheme(name string) string {
+	return ApplyThemeWithColors(name, nil)
+}
+
+// ApplyThemeWithColors applies a theme with individual palette colors
+// overridden by name (see ColorNames). Invalid colors are ignored.
+func ApplyThemeWithColors(name string, colors map[string]string) string {
 	theme := ResolveTheme(name)
 	currentThemeName = theme.Name
-	applyPalette(theme.Palette)
+
+	p := theme.Palette
+	fields := p.fields()
+	for colorName, value := range colors {
+		field, ok := fields[strings.ToLower(strings.TrimSpace(colorName))]
+		if !ok || !IsValidColor(value) {
+			continue
+		}
+		*field = strings.TrimSpace(value)
+	}
+
+	applyPalette(p)
 	return currentThemeName
 }
 
+func (p *palette) fields() map[string]*string {
+	return map[string]*string{
+		"primary":     &p.Primary,
+		"secondary":   &p.Secondary,
+		"muted":       &p.Muted,
+		"text":        &p.Text,
+		"dim_text":    &p.DimText,
+		"border":      &p.Border,
+		"selected_bg": &p.SelectedBg,
+		"error":       &p.Error,
+	}
+}
+
+// ColorNames lists the palette colors that can be overridden in config.
+func ColorNames() []string {
+	var p palette
+	names := make([]string, 0, 8)
+	for name := range p.fields() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var hexColorPattern = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// IsValidColor accepts #RGB / #RRGGBB hex colors and ANSI 256 color numbers.
+func IsValidColor(value string) bool {
+	value = strings.TrimSpace(value)
+	if hexColorPattern.MatchString(value) {
+		return true
+	}
+	n, err := strconv.Atoi(value)
+	return err == nil && n >= 0 && n <= 255
+}
+
+// IsKnownTheme reports whether name resolves to a theme without falling
+// back to the default.
+func IsKnownTheme(name string) bool {
+	_, ok := themeAliases[normalizeThemeName(name)]
+	return ok
+}
+
+// ThemeNames lists the canonical theme names.
+func ThemeNames() []string {
+	names := make([]string, 0, len(themePresets))
+	for name := range themePresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func CurrentThemeName() string {
 	return currentThemeName
 }
@@ -209,6 +302,9 @@ func applyPalette(p palette) {
 		Foreground(errorColor).
 		Bold(true)
 
+	pinnedMarkerStyle = lipgloss.NewStyle().
+		Foreground(primaryColor)
+
 	cursorStyle = lipgloss.NewStyle().
 		Foreground(primaryColor).
 		Bold(true)
@@ -233,6 +329,53 @@ func applyPalette(p palette) {
 		Italic(true).
 		Padding(1, 2)
 
+	noticeStyle = lipgloss.NewStyle().
+		Foreground(dimTextColor).
+		Italic(true)
+
+	matchHighlightStyle = lipgloss.NewStyle().
+		Foreground(primaryColor).
+		Bold(true)
+
+	treeCountStyle = lipgloss.NewStyle().
+		Foreground(mutedColor)
+
+	previewStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(borderColor).
+		Padding(0, 1)
+
+	previewHeadingStyle = lipgloss.NewStyle().
+		Foreground(secondaryColor).
+		Bold(true)
+
+	previewLineNumberStyle = lipgloss.NewStyle().
+		Foreground(mutedColor)
+
+	syntaxPlainStyle = lipgloss.NewStyle().
+		Foreground(textColor)
+
+	syntaxKeywordStyle = lipgloss.NewStyle().
+		Foreground(primaryColor).
+		Bold(true)
+
+	syntaxStringStyle = lipgloss.NewStyle().
+		Foreground(secondaryColor)
+
+	syntaxCommentStyle = lipgloss.NewStyle().
+		Foreground(mutedColor).
+		Italic(true)
+
+	syntaxAtomStyle = lipgloss.NewStyle().
+		Foreground(primaryColor)
+
+	syntaxModuleStyle = lipgloss.NewStyle().
+		Foreground(textColor).
+		Bold(true)
+
+	syntaxNumberStyle = lipgloss.NewStyle().
+		Foreground(secondaryColor)
+
 	bannerStyle = lipgloss.NewStyle().
 		Bold(true).
 		Foreground(primaryColor).
@@ -37,3 +37,25 @@ func TestApplyThemeUpdatesCurrentTheme(t *testing.T) {
 		t.Fatalf("CurrentThemeName = %q, want gruvbox", got)
 	}
 }
+
+func TestIsValidColor(t *testing.T) {
+	for _, c := range []string{"#fff", "#A1B2C3", "42", "255"} {
+		if !IsValidColor(c) {
+			t.Errorf("expected %q to be valid", c)
+		}
+	}
+	for _, c := range []string{"#12345", "red", "256", ""} {
+		if IsValidColor(c) {
+			t.Errorf("expected %q to be invalid", c)
+		}
+	}
+}
+
+func TestIsKnownTheme(t *testing.T) {
+	if !IsKnownTheme("Catppucin") {
+		t.Fatalf("expected alias to be a known theme")
+	}
+	if IsKnownTheme("solarized") {
+		t.Fatalf("expected unknown theme to be reported")
+	}
+}
@@ -0,0 +1,323 @@
+package tui
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/samrobinsonsauce/eztest/internal/config"
+)
+
+// treeRow is one line of the tree view: either a directory or a test file.
+type treeRow struct {
+	// dir is the full path of a directory row; empty for file rows.
+	dir   string
+	label string
+	depth int
+	// item indexes filteredItems for file rows and is -1 for directories.
+	item      int
+	collapsed bool
+	// items are the filteredItems below a directory row.
+	items []int
+}
+
+func (r treeRow) isDir() bool {
+	return r.item < 0
+}
+
+type treeNode struct {
+	path  string
+	label string
+	dirs  map[string]*treeNode
+	files []int
+}
+
+func newTreeNode(path, label string) *treeNode {
+	return &treeNode{path: path, label: label, dirs: map[string]*treeNode{}}
+}
+
+// treeSegments splits the directory of a test file into tree levels.
+// Umbrella apps are a single level so each app groups its own tests.
+func treeSegments(filePath string) []string {
+	dir := path.Dir(filePath)
+	if dir == "." {
+		return nil
+	}
+	parts := strings.Split(dir, "/")
+	if len(parts) >= 2 && parts[0] == "apps" {
+		return append([]string{"apps/" + parts[1]}, parts[2:]...)
+	}
+	return parts
+}
+
+// buildTree groups items by directory. Directories come before files and
+// are sorted by name, while files keep the order of items, so the sort mode
+// and search relevance still apply within each directory. A directory
+// whose only child is another directory is merged with it, so
+// test/my_app/ takes one row instead of two.
+func buildTree(items []Item, collapsed map[string]bool) []treeRow {
+	root := newTreeNode("", "")
+	for i, item := range items {
+		node := root
+		for _, seg := range treeSegments(item.TestFile.Path) {
+			child, ok := node.dirs[seg]
+			if !ok {
+				child = newTreeNode(path.Join(node.path, seg), seg)
+				node.dirs[seg] = child
+			}
+			node = child
+		}
+		node.files = append(node.files, i)
+	}
+
+	var rows []treeRow
+	appendTreeRows(&rows, root, 0, items, collapsed)
+	return rows
+}
+
+func appendTreeRows(rows *[]treeRow, node *treeNode, depth int, items []Item, collapsed map[string]bool) {
+	names := make([]string, 0, len(node.dirs))
+	for name := range node.dirs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		child := node.dirs[name]
+		label := child.label
+		for len(child.files) == 0 && len(child.dirs) == 1 {
+			for _, only := range child.dirs {
+				label += "/" + only.label
+				child = only
+			}
+		}
+
+		row := treeRow{dir: child.path, label: label + "/", depth: depth, item: -1, collapsed: collapsed[child.path]}
+		row.items = subtreeItems(child)
+		*rows = append(*rows, row)
+		if !row.collapsed {
+			appendTreeRows(rows, child, depth+1, items, collapsed)
+		}
+	}
+
+	for _, i := range node.files {
+		*rows = append(*rows, treeRow{label: path.Base(items[i].TestFile.Path), depth: depth, item: i})
+	}
+}
+
+func subtreeItems(node *treeNode) []int {
+	out := append([]int(nil), node.files...)
+	for _, child := range node.dirs {
+		out = append(out, subtreeItems(child)...)
+	}
+	return out
+}
+
+// rowCount is the number of rows the cursor can move over.
+func (m Model) rowCount() int {
+	if m.treeView {
+		return len(m.treeRows)
+	}
+	return len(m.filteredItems)
+}
+
+// refreshTree rebuilds the tree rows after the filter or collapsed
+// directories change.
+func (m *Model) refreshTree() {
+	if !m.treeView {
+		m.treeRows = nil
+		return
+	}
+	m.treeRows = buildTree(m.filteredItems, m.collapsed)
+}
+
+// toggleView switches between the flat list and the tree, keeping the cursor
+// on the same file where possible, and remembers the choice.
+func (m *Model) toggleView() {
+	current := m.cursorItem()
+	m.treeView = !m.treeView
+	m.refreshTree()
+
+	m.cursor = 0
+	if m.treeView {
+		for i, row := range m.treeRows {
+			if !row.isDir() && row.item == current {
+				m.cursor = i
+				break
+			}
+		}
+	} else if current >= 0 {
+		m.cursor = current
+	}
+	m.saveView()
+}
+
+// cursorItem returns the filteredItems index under the cursor, the first
+// file of a directory row, or -1.
+func (m Model) cursorItem() int {
+	if !m.treeView {
+		if m.cursor < len(m.filteredItems) {
+			return m.cursor
+		}
+		return -1
+	}
+	if m.cursor >= len(m.treeRows) {
+		return -1
+	}
+	row := m.treeRows[m.cursor]
+	if !row.isDir() {
+		return row.item
+	}
+	if len(row.items) > 0 {
+		return row.items[0]
+	}
+	return -1
+}
+
+// toggleCursorSelection toggles the file under the cursor. On a directory
+// row every file below it is selected, or deselected if all already are.
+func (m *Model) toggleCursorSelection() {
+	if m.treeView && m.cursor < len(m.treeRows) && m.treeRows[m.cursor].isDir() {
+		row := m.treeRows[m.cursor]
+		selected, _ := m.rowCounts(row)
+		value := selected < len(row.items)
+		for _, i := range row.items {
+			m.setItemSelected(i, value)
+		}
+		return
+	}
+	if i := m.cursorItem(); i >= 0 {
+		m.setItemSelected(i, !m.filteredItems[i].Selected)
+	}
+}
+
+// setItemSelected updates a filtered item and the item it was copied from.
+func (m *Model) setItemSelected(i int, selected bool) {
+	m.setPathSelected(m.filteredItems[i].TestFile.Path, selected)
+}
+
+// setPathSelected selects or deselects the file at path in both the full
+// and the filtered list.
+func (m *Model) setPathSelected(path string, selected bool) {
+	m.updateItem(path, func(item *Item) { item.Selected = selected })
+}
+
+// setCollapsed folds or unfolds the directory under the cursor. Collapsing
+// a file row, or a directory that is already folded, folds its parent and
+// moves the cursor there.
+func (m *Model) setCollapsed(collapse bool) {
+	if !m.treeView || m.cursor >= len(m.treeRows) {
+		return
+	}
+	row := m.treeRows[m.cursor]
+	target := row.dir
+	if collapse && (!row.isDir() || row.collapsed) {
+		target = ""
+		for i := m.cursor - 1; i >= 0; i-- {
+			if m.treeRows[i].isDir() && m.treeRows[i].depth < row.depth {
+				target = m.treeRows[i].dir
+				break
+			}
+		}
+	}
+	if target == "" || m.collapsed[target] == collapse {
+		return
+	}
+
+	if collapse {
+		m.collapsed[target] = true
+	} else {
+		delete(m.collapsed, target)
+	}
+	m.refreshTree()
+	for i, r := range m.treeRows {
+		if r.dir == target {
+			m.cursor = i
+			break
+		}
+	}
+	m.saveView()
+}
+
+func (m Model) rowCounts(row treeRow) (selected, failed int) {
+	for _, i := range row.items {
+		if m.filteredItems[i].Selected {
+			selected++
+		}
+		if m.filteredItems[i].Failed {
+			failed++
+		}
+	}
+	return selected, failed
+}
+
+func (m Model) saveView() {
+	_ = config.SaveProjectView(m.projectDir, m.treeView, m.collapsedDirs())
+}
+
+func (m Model) collapsedDirs() []string {
+	collapsed := make([]string, 0, len(m.collapsed))
+	for dir := range m.collapsed {
+		collapsed = append(collapsed, dir)
+	}
+	sort.Strings(collapsed)
+	return collapsed
+}
+
+// renderTreeRow renders a directory row with its counts, or a file row by
+// its base name indented under its directory.
+func (m Model) renderTreeRow(row treeRow, index, width int) string {
+	indent := strings.Repeat("  ", row.depth)
+	if !row.isDir() {
+		item := m.filteredItems[row.item]
+		base := len(item.TestFile.Path) - len(row.label)
+		var positions []int
+		for _, p := range item.MatchedIndexes {
+			if p >= base {
+				positions = append(positions, p-base+len(indent))
+			}
+		}
+		item.TestFile.Path = indent + row.label
+		item.MatchedIndexes = positions
+		cursor := m.cursor
+		if m.inVisualRange(index) {
+			cursor = index
+		}
+		return RenderItem(item, index, cursor, width, m.frame, m.animations)
+	}
+
+	isCursor := index == m.cursor || m.inVisualRange(index)
+	cursorIndicator := noCursorStyle.Render(" ")
+	if isCursor {
+		cursorIndicator = cursorStyle.Render("▸")
+	}
+
+	selected, failed := m.rowCounts(row)
+	checkbox := checkboxUncheckedStyle.Render("[ ]")
+	switch {
+	case selected > 0 && selected == len(row.items):
+		checkbox = checkboxCheckedStyle.Render("[✓]")
+	case selected > 0:
+		checkbox = checkboxCheckedStyle.Render("[-]")
+	}
+	failureMarker := failedMarkerStyle.Render(" ")
+	if failed > 0 {
+		failureMarker = failedMarkerStyle.Render("✗")
+	}
+
+	arrow := "▾"
+	if row.collapsed {
+		arrow = "▸"
+	}
+	counts := fmt.Sprintf("%d/%d selected", selected, len(row.items))
+	if failed > 0 {
+		counts += fmt.Sprintf(" • %d failing", failed)
+	}
+
+	line := cursorIndicator + " " + checkbox + " " + failureMarker + " " + indent + arrow + " " + row.label + "  " + treeCountStyle.Render(counts)
+	if isCursor {
+		return selectedItemStyle.Width(width).Render(line)
+	}
+	return itemStyle.Width(width).Render(line)
+}
@@ -0,0 +1,122 @@
+package tui
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/samrobinsonsauce/eztest/internal/config"
+)
+
+func testModelForTree(t *testing.T) Model {
+	return newTestModel(t, []string{
+		"test/my_app/accounts/user_test.exs",
+		"test/my_app/accounts/team_test.exs",
+		"test/my_app/billing_test.exs",
+		"apps/web/test/page_test.exs",
+	}, withFailed("test/my_app/accounts/team_test.exs"))
+}
+
+func treeLabels(rows []treeRow) []string {
+	labels := make([]string, len(rows))
+	for i, row := range rows {
+		labels[i] = row.label
+	}
+	return labels
+}
+
+func TestBuildTreeGroupsByDirectoryAndApp(t *testing.T) {
+	m := testModelForTree(t)
+	rows := buildTree(m.filteredItems, map[string]bool{})
+
+	want := []string{
+		"apps/web/test/",
+		"page_test.exs",
+		"test/my_app/",
+		"accounts/",
+		"user_test.exs",
+		"team_test.exs",
+		"billing_test.exs",
+	}
+	if got := treeLabels(rows); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected tree:\n got %v\nwant %v", got, want)
+	}
+	if rows[3].depth != 1 || len(rows[3].items) != 2 {
+		t.Fatalf("expected accounts/ one level down with two files, got %+v", rows[3])
+	}
+}
+
+func TestTreeKeepsTheSortOrderWithinDirectories(t *testing.T) {
+	m := newTestModel(t, []string{"test/accounts/team_test.exs", "test/accounts/user_test.exs"}, withFailed("test/accounts/user_test.exs")).
+		WithSort(SortFailures, nil)
+	m.toggleView()
+	if got, want := treeLabels(m.treeRows), []string{"test/accounts/", "user_test.exs", "team_test.exs"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected the failed file first in the tree, got %v", got)
+	}
+
+	m.sortMode = SortPath
+	m.applySort()
+	if got, want := treeLabels(m.treeRows), []string{"test/accounts/", "team_test.exs", "user_test.exs"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected files in path order, got %v", got)
+	}
+}
+
+func TestTreeDirectorySelectionAndCollapse(t *testing.T) {
+	m := testModelForTree(t)
+	m.toggleView()
+	if !m.treeView {
+		t.Fatalf("expected tree view after toggling")
+	}
+
+	m.cursor = 3 // accounts/
+	m.toggleCursorSelection()
+	if got, want := m.getSelectedFiles(), []string{"test/my_app/accounts/user_test.exs", "test/my_app/accounts/team_test.exs"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected directory selection to select its files, got %v", got)
+	}
+	if selected, failed := m.rowCounts(m.treeRows[2]); selected != 2 || failed != 1 {
+		t.Fatalf("unexpected counts for test/my_app/: %d selected, %d failed", selected, failed)
+	}
+
+	m.toggleCursorSelection()
+	if got := m.getSelectedFiles(); len(got) != 0 {
+		t.Fatalf("expected a fully selected directory to be deselected, got %v", got)
+	}
+
+	m.setCollapsed(true)
+	if got := len(m.treeRows); got != 5 {
+		t.Fatalf("expected collapsed directory to hide its files, got %v", treeLabels(m.treeRows))
+	}
+
+	m.cursor = 4 // billing_test.exs
+	m.setCollapsed(true)
+	if got := treeLabels(m.treeRows); len(got) != 3 || m.cursor != 2 {
+		t.Fatalf("expected collapsing a file to fold its parent, got %v (cursor %d)", got, m.cursor)
+	}
+
+	m.setCollapsed(false)
+	if got := len(m.treeRows); got != 5 {
+		t.Fatalf("expected expanding to restore the directory, got %v", treeLabels(m.treeRows))
+	}
+
+	tree, collapsed, err := config.GetProjectView(m.projectDir)
+	if err != nil {
+		t.Fatalf("GetProjectView returned error: %v", err)
+	}
+	if !tree || !reflect.DeepEqual(collapsed, []string{"test/my_app/accounts"}) {
+		t.Fatalf("expected view to be remembered, got tree=%v collapsed=%v", tree, collapsed)
+	}
+}
+
+func TestVisibleWindowKeepsCursorInView(t *testing.T) {
+	heights := []int{1, 2, 2, 1, 1, 2, 1}
+	start, end := visibleWindow(heights, 5, 4)
+	if start > 5 || end <= 5 {
+		t.Fatalf("cursor row 5 not in window [%d, %d)", start, end)
+	}
+	lines := 0
+	for _, h := range heights[start:end] {
+		lines += h
+	}
+	if lines > 4 {
+		t.Fatalf("window [%d, %d) uses %d lines, want at most 4", start, end, lines)
+	}
+}
@@ -0,0 +1,98 @@
+package tui
+
+import "fmt"
+
+// maxUndo caps how many selection changes can be undone.
+const maxUndo = 100
+
+// selectionChange is one undoable change: the selection, the name of the
+// active set and the selection that cycling sets returns to, before and
+// after it. Sets are kept by name because saving a set can reorder them.
+type selectionChange struct {
+	desc                  string
+	before, after         []string
+	setBefore, setAfter   string
+	baseBefore, baseAfter []string
+}
+
+// changeSelection runs apply and records the selection change it makes so
+// it can be undone. It returns how many files changed; changes that leave
+// the selection as it was are not recorded.
+func (m *Model) changeSelection(desc string, apply func()) int {
+	before, setBefore, baseBefore := m.getSelectedFiles(), m.ActiveSet(), m.baseSelection
+	apply()
+	after := m.getSelectedFiles()
+	changed := changedFiles(before, after)
+	if changed == 0 && setBefore == m.ActiveSet() {
+		return 0
+	}
+
+	m.undoStack = append(m.undoStack, selectionChange{
+		desc:       desc,
+		before:     before,
+		after:      after,
+		setBefore:  setBefore,
+		setAfter:   m.ActiveSet(),
+		baseBefore: baseBefore,
+		baseAfter:  m.baseSelection,
+	})
+	if len(m.undoStack) > maxUndo {
+		m.undoStack = m.undoStack[1:]
+	}
+	m.redoStack = nil
+	return changed
+}
+
+func (m *Model) undo() {
+	if len(m.undoStack) == 0 {
+		m.notice = "Nothing to undo"
+		return
+	}
+	change := m.undoStack[len(m.undoStack)-1]
+	m.undoStack = m.undoStack[:len(m.undoStack)-1]
+	m.redoStack = append(m.redoStack, change)
+
+	m.applySelection(change.before)
+	m.setIndex = m.setIndexOf(change.setBefore)
+	m.baseSelection = change.baseBefore
+	m.notice = fmt.Sprintf("Undid %s (%s)", change.desc, filesLabel(changedFiles(change.after, change.before)))
+}
+
+func (m *Model) redo() {
+	if len(m.redoStack) == 0 {
+		m.notice = "Nothing to redo"
+		return
+	}
+	change := m.redoStack[len(m.redoStack)-1]
+	m.redoStack = m.redoStack[:len(m.redoStack)-1]
+	m.undoStack = append(m.undoStack, change)
+
+	m.applySelection(change.after)
+	m.setIndex = m.setIndexOf(change.setAfter)
+	m.baseSelection = change.baseAfter
+	m.notice = fmt.Sprintf("Redid %s (%s)", change.desc, filesLabel(changedFiles(change.before, change.after)))
+}
+
+// changedFiles counts the paths selected in exactly one of a and b.
+func changedFiles(a, b []string) int {
+	inA := make(map[string]bool, len(a))
+	for _, p := range a {
+		inA[p] = true
+	}
+	count := len(a)
+	for _, p := range b {
+		if inA[p] {
+			count--
+		} else {
+			count++
+		}
+	}
+	return count
+}
+
+func filesLabel(n int) string {
+	if n == 1 {
+		return "1 file"
+	}
+	return fmt.Sprintf("%d files", n)
+}
@@ -0,0 +1,91 @@
+package tui
+
+import (
+	"reflect"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestUndoRedoSelectionChanges(t *testing.T) {
+	m := newTestModel(t, []string{"test/a_test.exs", "test/b_test.exs", "test/c_test.exs"}, withSelected("test/b_test.exs"))
+	send := func(msg tea.KeyMsg) {
+		updated, _ := m.Update(msg)
+		m = updated.(Model)
+	}
+
+	send(tea.KeyMsg{Type: tea.KeyCtrlZ})
+	if m.notice != "Nothing to undo" {
+		t.Fatalf("expected a notice with an empty history, got %q", m.notice)
+	}
+
+	send(tea.KeyMsg{Type: tea.KeyTab})
+	send(tea.KeyMsg{Type: tea.KeyCtrlD})
+	if got := m.getSelectedFiles(); len(got) != 0 {
+		t.Fatalf("expected everything deselected, got %v", got)
+	}
+
+	send(tea.KeyMsg{Type: tea.KeyCtrlZ})
+	if got := m.getSelectedFiles(); !reflect.DeepEqual(got, []string{"test/a_test.exs", "test/b_test.exs"}) {
+		t.Fatalf("expected undo to restore the selection, got %v", got)
+	}
+	if m.notice != "Undid deselect all (2 files)" {
+		t.Fatalf("unexpected undo notice %q", m.notice)
+	}
+
+	send(tea.KeyMsg{Type: tea.KeyCtrlZ})
+	send(tea.KeyMsg{Type: tea.KeyCtrlY})
+	if got := m.getSelectedFiles(); !reflect.DeepEqual(got, []string{"test/a_test.exs", "test/b_test.exs"}) || m.notice != "Redid toggle (1 file)" {
+		t.Fatalf("expected redo to reapply the toggle, got %v (%q)", got, m.notice)
+	}
+
+	send(tea.KeyMsg{Type: tea.KeyCtrlA})
+	send(tea.KeyMsg{Type: tea.KeyCtrlY})
+	if m.notice != "Nothing to redo" {
+		t.Fatalf("expected a new change to clear the redo history, got %q", m.notice)
+	}
+}
+
+func TestUndoInNormalModeCoversVisualRanges(t *testing.T) {
+	m := testModelForModal(t)
+
+	m = pressKeys(m, "V", "j", "j", "x")
+	if got := len(m.getSelectedFiles()); got != 3 {
+		t.Fatalf("expected the range to be selected, got %d files", got)
+	}
+	m = pressKeys(m, "u")
+	if got := m.getSelectedFiles(); len(got) != 0 || m.notice != "Undid range selection (3 files)" {
+		t.Fatalf("expected u to undo the range, got %v (%q)", got, m.notice)
+	}
+}
+
+func TestUndoAfterSavingASetKeepsTheActiveSet(t *testing.T) {
+	m := newTestModel(t, []string{"test/a_test.exs", "test/b_test.exs", "test/c_test.exs"}, withSelected("test/a_test.exs")).
+		WithSets([]NamedSet{{Name: "smoke", Files: []string{"test/b_test.exs"}}})
+
+	m.handleAction(actionNextSet)
+	m.cursor = 2
+	m.changeSelection("toggle", m.toggleCursorSelection)
+	m.saveSet("alpha") // sorts before smoke
+	if m.ActiveSet() != "alpha" {
+		t.Fatalf("expected the saved set to be active, got %q", m.ActiveSet())
+	}
+
+	m.undo()
+	if got := m.getSelectedFiles(); m.ActiveSet() != "smoke" || !reflect.DeepEqual(got, []string{"test/b_test.exs"}) {
+		t.Fatalf("expected undo to return to smoke, got set %q selection %v", m.ActiveSet(), got)
+	}
+	m.undo()
+	if got := m.getSelectedFiles(); m.ActiveSet() != "" || !reflect.DeepEqual(got, []string{"test/a_test.exs"}) || m.baseSelection != nil {
+		t.Fatalf("expected undo to leave the sets, got set %q selection %v base %v", m.ActiveSet(), got, m.baseSelection)
+	}
+
+	m.redo()
+	if m.ActiveSet() != "smoke" || !reflect.DeepEqual(m.baseSelection, []string{"test/a_test.exs"}) {
+		t.Fatalf("expected redo to switch back to smoke, got set %q base %v", m.ActiveSet(), m.baseSelection)
+	}
+	m.handleAction(actionNextSet)
+	if got := m.getSelectedFiles(); m.ActiveSet() != "" || !reflect.DeepEqual(got, []string{"test/a_test.exs"}) {
+		t.Fatalf("expected cycling past the last set to restore the base selection, got set %q selection %v", m.ActiveSet(), got)
+	}
+}
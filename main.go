@@ -1,14 +1,20 @@
 package main
 
 import (
+	"bufio"
 	"errors"
-	"flag"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/samrobinsonsauce/eztest/internal/config"
+	"github.com/samrobinsonsauce/eztest/internal/search"
 	"github.com/samrobinsonsauce/eztest/internal/testfile"
 	"github.com/samrobinsonsauce/eztest/internal/tui"
 )
@@ -21,153 +27,319 @@ var (
 )
 
 func main() {
-	showVersion := flag.Bool("version", false, "Show version information")
-	showHelp := flag.Bool("help", false, "Show help")
-	runDirect := flag.Bool("r", false, "Run saved tests directly without opening TUI")
-	runFailed := flag.Bool("f", false, "Run last failed tests directly without opening TUI")
-	flag.Parse()
-
-	if *showHelp {
-		printHelp()
-		os.Exit(0)
-	}
+	os.Exit(execute(newRootCommand(), os.Args[1:], os.Stdout, os.Stderr))
+}
 
-	if *showVersion {
-		fmt.Printf("ezt version %s (commit: %s, built: %s)\n", version, commit, date)
-		os.Exit(0)
-	}
+// project bundles the settings and discovered test files for the current
+// directory, which nearly every command needs.
+type project struct {
+	dir      string
+	settings config.AppSettings
+	files    []testfile.TestFile
+}
 
-	cwd, err := os.Getwd()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: could not get current directory: %v\n", err)
-		os.Exit(1)
+// loadProject reads the layered settings for the working directory, applies
+// the theme and discovers test files. Problems are reported on stderr.
+func loadProject(flags config.FlagOverrides) (project, bool) {
+	cwd, ok := workingDir()
+	if !ok {
+		return project{}, false
 	}
 
-	appSettings, err := config.LoadAppSettings()
+	settings, err := config.LoadSettings(cwd, flags)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
-		appSettings = config.DefaultAppSettings()
 	}
-	tui.ApplyTheme(appSettings.Theme)
+	tui.ApplyThemeWithColors(settings.Theme, settings.Colors)
 
-	if *runDirect && *runFailed {
-		fmt.Fprintf(os.Stderr, "Use either -r or -f, not both.\n")
-		os.Exit(1)
+	testFiles, err := testfile.Discover(cwd, discoveryOptions(settings))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return project{}, false
 	}
+	return project{dir: cwd, settings: settings, files: testFiles}, true
+}
 
-	if *runDirect {
-		selections, err := config.GetProjectSelections(cwd)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error loading saved tests: %v\n", err)
-			os.Exit(1)
-		}
-		if len(selections) == 0 {
-			fmt.Fprintf(os.Stderr, "No tests saved. Run 'ezt' first to select tests.\n")
-			os.Exit(1)
-		}
-		os.Exit(runAndPersistFailures(cwd, selections))
+// runTUI opens the selector and runs whatever the user picks. When preselect
+// is non-empty it replaces the saved selection. ttyInput makes the TUI read
+// keys from the terminal because stdin has already been consumed.
+func runTUI(p project, preselect []string, ttyInput bool) int {
+	state, err := config.LoadProjectState(p.dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not load saved state: %v\n", err)
+		state = &config.ProjectState{}
 	}
 
-	if *runFailed {
-		failures, err := config.GetProjectFailures(cwd)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error loading failed tests: %v\n", err)
-			os.Exit(1)
-		}
-		if len(failures) == 0 {
-			fmt.Fprintf(os.Stderr, "No failed tests saved. Run tests first to capture failures.\n")
-			os.Exit(1)
-		}
-		os.Exit(runAndPersistFailures(cwd, failures))
+	selections := preselect
+	if len(selections) == 0 {
+		selections = reconcileSaved(p.dir, "selection", state.Selections, p.files, config.SaveProjectSelections)
 	}
-
-	testFiles, err := testfile.FindTestFiles(cwd)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+	failures := reconcileSaved(p.dir, "failure", state.Failures, p.files, config.SaveProjectFailures)
+	var session config.Session
+	if p.settings.UI.RestoreSession {
+		session = config.Session{Query: state.Query, Cursor: state.Cursor}
 	}
-
-	selections, err := config.GetProjectSelections(cwd)
+	pinned := resolveFileList(p, "pinned file", p.settings.Pinned, state.Pinned, config.SaveProjectPinned)
+	hidden := resolveFileList(p, "hidden file", p.settings.Hidden, state.Hidden, config.SaveProjectHidden)
+	outlineCache, err := config.GetProjectCachePath(p.dir, "outline")
 	if err != nil {
-		selections = []string{}
+		outlineCache = ""
 	}
 
 	model := tui.NewModel(
-		testFiles,
-		cwd,
+		p.files,
+		p.dir,
 		selections,
-		failuresForProject(cwd),
-		tui.NewKeyMap(appSettings.Keybinds),
-		appSettings.UI,
-	)
-	p := tea.NewProgram(model, tea.WithAltScreen())
+		failures,
+		tui.NewKeyMap(p.settings.Keybinds),
+		p.settings.UI,
+	).WithSets(resolveNamedSets(p.dir, state.Sets, p.settings, p.files)).
+		WithChanged(testfile.ChangedTests(p.dir, p.files)).
+		WithContentIndex(outlineCache).
+		WithView(state.TreeView, state.Collapsed).
+		WithPins(pinned, hidden).
+		WithSort(state.SortMode, state.History).
+		WithFailureMessages(state.FailureMessages).
+		WithOpenCommand(p.settings.Open.Command).
+		WithCustomActions(customActions(p.settings)).
+		WithModal(p.settings.UI.Modal).
+		WithSearchHistory(state.SearchHistory).
+		WithSession(session.Query, session.Cursor)
+	opts := []tea.ProgramOption{tea.WithAltScreen()}
+	if p.settings.UI.Mouse {
+		opts = append(opts, tea.WithMouseCellMotion())
+	}
+	if ttyInput {
+		opts = append(opts, tea.WithInputTTY())
+	}
+	program := tea.NewProgram(model, opts...)
 
-	finalModel, err := p.Run()
+	finalModel, err := program.Run()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error running TUI: %v\n", err)
-		os.Exit(1)
+		return 1
 	}
 
 	m, ok := finalModel.(tui.Model)
 	if !ok {
-		os.Exit(1)
+		return 1
 	}
 
 	if m.IsQuitting() {
-		os.Exit(0)
+		return 0
 	}
 
 	files := m.GetFilesToRun()
 	if len(files) == 0 {
 		fmt.Println("No tests selected.")
-		os.Exit(0)
+		return 0
 	}
 
-	os.Exit(runAndPersistFailures(cwd, files))
+	return runAndPersistFailures(p.dir, p.settings.Run, files)
+}
+
+func runSavedSelection(p project) int {
+	selections, err := config.GetProjectSelections(p.dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading saved tests: %v\n", err)
+		return 1
+	}
+	selections = reconcileSaved(p.dir, "selection", selections, p.files, config.SaveProjectSelections)
+	if len(selections) == 0 {
+		fmt.Fprintf(os.Stderr, "No tests saved. Run 'ezt' first to select tests.\n")
+		return 1
+	}
+	return runAndPersistFailures(p.dir, p.settings.Run, selections)
 }
 
-func printHelp() {
-	configPath, err := config.GetAppConfigPath()
+func runLastFailures(p project) int {
+	failures, err := config.GetProjectFailures(p.dir)
 	if err != nil {
-		configPath = "~/.config/eztest/config.json"
+		fmt.Fprintf(os.Stderr, "Error loading failed tests: %v\n", err)
+		return 1
 	}
+	failures = reconcileSaved(p.dir, "failure", failures, p.files, config.SaveProjectFailures)
+	if len(failures) == 0 {
+		fmt.Fprintf(os.Stderr, "No failed tests saved. Run tests first to capture failures.\n")
+		return 1
+	}
+	return runAndPersistFailures(p.dir, p.settings.Run, failures)
+}
 
-	help := fmt.Sprintf(`ezt - Elixir Test Selector
+func runNamedSet(p project, name string) int {
+	set, ok := findNamedSet(resolveNamedSets(p.dir, savedSets(p.dir), p.settings, p.files), name)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "No set named %q. Run 'ezt sets' to list them.\n", name)
+		return 1
+	}
+	if len(set.Files) == 0 {
+		fmt.Fprintf(os.Stderr, "Set %q matches no test files.\n", set.Name)
+		return 1
+	}
+	return runAndPersistFailures(p.dir, p.settings.Run, set.Files)
+}
 
-A TUI for selecting and running Elixir tests.
+// resolveInputs maps paths given as arguments or on stdin to discovered
+// test files. Test files, directories and globs are matched directly and
+// source files are mapped to their tests. A trailing :LINE is kept when the
+// input names exactly one test. Inputs that match nothing are returned in
+// skipped.
+func resolveInputs(projectDir string, inputs []string, testFiles []testfile.TestFile) (files, skipped []string) {
+	seen := map[string]struct{}{}
+	for _, input := range inputs {
+		pathPart, line := splitLineSuffix(input)
+		if filepath.IsAbs(pathPart) {
+			if rel, err := filepath.Rel(projectDir, pathPart); err == nil && !strings.HasPrefix(rel, "..") {
+				pathPart = filepath.ToSlash(rel)
+			}
+		}
 
-USAGE:
-    ezt [OPTIONS]
+		matched := testfile.TestsForPath(pathPart, testFiles)
+		if len(matched) == 0 {
+			skipped = append(skipped, input)
+			continue
+		}
+		if len(matched) != 1 {
+			line = ""
+		}
+		for _, path := range matched {
+			path += line
+			if _, ok := seen[path]; ok {
+				continue
+			}
+			seen[path] = struct{}{}
+			files = append(files, path)
+		}
+	}
+	return files, skipped
+}
 
-OPTIONS:
-    -r           Run saved tests directly (skip TUI)
-    -f           Run last failed tests directly (skip TUI)
-    --help       Show this help message
-    --version    Show version information
+// readInputs reads one path per line, ignoring blank lines.
+func readInputs(r io.Reader) ([]string, error) {
+	var inputs []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			inputs = append(inputs, line)
+		}
+	}
+	return inputs, scanner.Err()
+}
 
-KEYBINDINGS:
-    (defaults shown below; configurable in %s)
-    ↑ / Ctrl+k   Move cursor up
-    ↓ / Ctrl+j   Move cursor down
-    Tab          Toggle selection on current item
-    Ctrl+a       Select all visible (filtered) items
-    Ctrl+d       Deselect all items
-    Enter        Run selected tests with mix test
-    Ctrl+s       Save selections and quit (without running)
-    Esc          Quit without saving
+// gatherInputs combines positional paths with stdin (when requested) and
+// resolves them against the project. It reports skipped inputs and fails
+// when inputs were given but none of them matched.
+func gatherInputs(p project, args []string, fromStdin bool) ([]string, bool) {
+	inputs := append([]string{}, args...)
+	if fromStdin {
+		lines, err := readInputs(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading stdin: %v\n", err)
+			return nil, false
+		}
+		inputs = append(inputs, lines...)
+	}
+	if len(inputs) == 0 {
+		return nil, true
+	}
 
-EXAMPLES:
-    ezt          Open TUI to select and run tests
-    ezt -r       Run previously saved tests directly
-    ezt -f       Run previously failed tests directly
+	files, skipped := resolveInputs(p.dir, inputs, p.files)
+	if len(skipped) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: %d path(s) matched no test file and were skipped:\n", len(skipped))
+		for _, input := range skipped {
+			fmt.Fprintf(os.Stderr, "  %s\n", input)
+		}
+	}
+	if len(files) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: none of the given paths match a test file.\n")
+		return nil, false
+	}
+	return files, true
+}
 
-USAGE:
-    Navigate to your Elixir/Phoenix project and run 'ezt'.
-    Type to filter tests, use Tab to select, Enter to run.
+// filterFiles ranks the project's test files against a search query the
+// same way the TUI search box does. Saved failures, saved selections and
+// git back the state tokens such as @failed.
+func filterFiles(p project, query string) ([]string, error) {
+	q, err := search.Parse(query)
+	if err != nil {
+		return nil, err
+	}
 
-    Selections are saved per-project in ~/.config/eztest/state.json
-`, configPath)
-	fmt.Print(help)
+	failed := stringSet(failuresForProject(p.dir))
+	selections, err := config.GetProjectSelections(p.dir)
+	if err != nil {
+		selections = nil
+	}
+	selected := stringSet(selections)
+	changed := stringSet(testfile.ChangedTests(p.dir, p.files))
+
+	var index testfile.ContentIndex
+	if q.ContentMode() {
+		cachePath, err := config.GetProjectCachePath(p.dir, "outline")
+		if err != nil {
+			cachePath = ""
+		}
+		index = testfile.BuildContentIndex(p.files, cachePath)
+	}
+
+	candidates := make([]search.Candidate, len(p.files))
+	for i, tf := range p.files {
+		_, isFailed := failed[tf.Path]
+		_, isSelected := selected[tf.Path]
+		_, isChanged := changed[tf.Path]
+		candidates[i] = search.Candidate{Path: tf.Path, Failed: isFailed, Selected: isSelected, Changed: isChanged}
+		if index != nil {
+			candidates[i].Content = search.OutlineContent(index[tf.Path])
+		}
+	}
+
+	// Content matches point at the matching line so `run` can target it.
+	matches := q.Apply(candidates)
+	paths := make([]string, len(matches))
+	for i, m := range matches {
+		paths[i] = candidates[m.Index].Path
+		if m.Line > 0 {
+			paths[i] = fmt.Sprintf("%s:%d", paths[i], m.Line)
+		}
+	}
+	return paths, nil
+}
+
+func stringSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+	return set
+}
+
+func stripLineSuffixes(paths []string) []string {
+	out := make([]string, 0, len(paths))
+	seen := map[string]struct{}{}
+	for _, path := range paths {
+		path, _ = splitLineSuffix(path)
+		if _, ok := seen[path]; ok {
+			continue
+		}
+		seen[path] = struct{}{}
+		out = append(out, path)
+	}
+	return out
+}
+
+// splitLineSuffix splits "test/foo_test.exs:42" into the path and ":42".
+func splitLineSuffix(arg string) (string, string) {
+	i := strings.LastIndexByte(arg, ':')
+	if i < 0 || i == len(arg)-1 {
+		return arg, ""
+	}
+	for _, r := range arg[i+1:] {
+		if r < '0' || r > '9' {
+			return arg, ""
+		}
+	}
+	return arg[:i], arg[i:]
 }
 
 func failuresForProject(projectDir string) []string {
@@ -178,12 +350,58 @@ func failuresForProject(projectDir string) []string {
 	return failures
 }
 
-func runAndPersistFailures(projectDir string, files []string) int {
-	outcome, err := executeMixTest(files)
+// reconcileSaved validates saved paths against the discovered test files.
+// Renamed files are migrated in place, unresolvable entries are reported and
+// left in state until pruned. The returned paths are safe to hand to mix.
+func reconcileSaved(projectDir, kind string, saved []string, testFiles []testfile.TestFile, save func(string, []string) error) []string {
+	res := testfile.ResolveSaved(projectDir, saved, testFiles)
+
+	if len(res.Renamed) > 0 {
+		if err := save(projectDir, append(append([]string{}, res.Paths...), res.Missing...)); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to migrate renamed %ss: %v\n", kind, err)
+		}
+		for _, from := range sortedKeys(res.Renamed) {
+			fmt.Fprintf(os.Stderr, "Moved saved %s %s -> %s\n", kind, from, res.Renamed[from])
+		}
+	}
+
+	if len(res.Missing) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: %d saved %s(s) no longer exist and will be skipped:\n", len(res.Missing), kind)
+		for _, path := range res.Missing {
+			fmt.Fprintf(os.Stderr, "  %s\n", path)
+		}
+		fmt.Fprintf(os.Stderr, "Run 'ezt state prune' to remove them.\n")
+	}
+
+	return res.Paths
+}
+
+// resolveFileList returns a project's saved list of files, or the files
+// matching the config's default patterns when it never saved one (saved is
+// nil).
+func resolveFileList(p project, kind string, defaults []string, saved *[]string, save func(string, []string) error) []string {
+	if saved == nil {
+		return testfile.SelectMatching(p.files, nil, defaults, nil)
+	}
+	return reconcileSaved(p.dir, kind, *saved, p.files, save)
+}
+
+func runAndPersistFailures(projectDir string, run config.RunSettings, files []string) int {
+	started := time.Now()
+	outcome, err := executeMixTest(run, files)
 
 	var exitErr *exec.ExitError
 	if err == nil || errors.As(err, &exitErr) {
-		if saveErr := config.SaveProjectFailures(projectDir, outcome.FailedFiles); saveErr != nil {
+		record := config.RunRecord{
+			StartedAt: started.UTC(),
+			Duration:  time.Since(started).Round(time.Millisecond),
+			Files:     stripLineSuffixes(files),
+			Failed:    outcome.FailedFiles,
+		}
+		if exitErr != nil {
+			record.ExitCode = exitErr.ExitCode()
+		}
+		if saveErr := config.SaveProjectRun(projectDir, record, outcome.FailureMessages); saveErr != nil {
 			fmt.Fprintf(os.Stderr, "Warning: failed to persist failed tests: %v\n", saveErr)
 		}
 	}
@@ -199,3 +417,46 @@ func runAndPersistFailures(projectDir string, files []string) int {
 	fmt.Fprintf(os.Stderr, "Error running mix test: %v\n", err)
 	return 1
 }
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func countTrue(values ...bool) int {
+	n := 0
+	for _, v := range values {
+		if v {
+			n++
+		}
+	}
+	return n
+}
+
+func discoveryOptions(settings config.AppSettings) testfile.Options {
+	return testfile.Options{Paths: settings.TestPaths, Exclude: settings.Exclude}
+}
+
+// customActions lists the config's custom actions sorted by name.
+func customActions(settings config.AppSettings) []tui.CustomAction {
+	actions := make([]tui.CustomAction, 0, len(settings.Actions))
+	for name, a := range settings.Actions {
+		actions = append(actions, tui.CustomAction{Name: name, Description: a.Description, Command: a.Command, Keys: a.Keys})
+	}
+	sort.Slice(actions, func(i, j int) bool { return actions[i].Name < actions[j].Name })
+	return actions
+}
+
+func splitCommaList(value string) []string {
+	var out []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
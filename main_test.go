@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/samrobinsonsauce/eztest/internal/config"
+	"github.com/samrobinsonsauce/eztest/internal/testfile"
 	"github.com/samrobinsonsauce/eztest/internal/tui"
 )
 
@@ -15,20 +16,21 @@ func setupConfigEnv(t *testing.T) {
 	home := t.TempDir()
 	t.Setenv("HOME", home)
 	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("XDG_STATE_HOME", filepath.Join(home, ".state"))
 }
 
 func TestRunAndPersistFailuresSuccess(t *testing.T) {
 	setupConfigEnv(t)
 
 	original := executeMixTest
-	executeMixTest = func(files []string) (tui.TestRunOutcome, error) {
+	executeMixTest = func(run config.RunSettings, files []string) (tui.TestRunOutcome, error) {
 		return tui.TestRunOutcome{FailedFiles: []string{"test/a_test.exs"}}, nil
 	}
 	t.Cleanup(func() {
 		executeMixTest = original
 	})
 
-	code := runAndPersistFailures("/tmp/project", []string{"test/a_test.exs", "test/b_test.exs"})
+	code := runAndPersistFailures("/tmp/project", config.RunSettings{}, []string{"test/a_test.exs", "test/b_test.exs"})
 	if code != 0 {
 		t.Fatalf("expected exit code 0, got %d", code)
 	}
@@ -43,6 +45,35 @@ func TestRunAndPersistFailuresSuccess(t *testing.T) {
 	}
 }
 
+func TestRunAndPersistFailuresRecordsFilesWithoutLineSuffixes(t *testing.T) {
+	setupConfigEnv(t)
+
+	original := executeMixTest
+	executeMixTest = func(run config.RunSettings, files []string) (tui.TestRunOutcome, error) {
+		return tui.TestRunOutcome{}, nil
+	}
+	t.Cleanup(func() {
+		executeMixTest = original
+	})
+
+	files := []string{"test/a_test.exs:12", "test/a_test.exs:40", "test/b_test.exs"}
+	if code := runAndPersistFailures("/tmp/project", config.RunSettings{}, files); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+
+	history, err := config.GetProjectHistory("/tmp/project")
+	if err != nil {
+		t.Fatalf("GetProjectHistory returned error: %v", err)
+	}
+	if len(history) != 1 {
+		t.Fatalf("expected one recorded run, got %d", len(history))
+	}
+	want := []string{"test/a_test.exs", "test/b_test.exs"}
+	if !reflect.DeepEqual(history[0].Files, want) {
+		t.Fatalf("unexpected recorded files: got %v want %v", history[0].Files, want)
+	}
+}
+
 func TestRunAndPersistFailuresSkipsPersistenceOnGenericError(t *testing.T) {
 	setupConfigEnv(t)
 	project := "/tmp/project2"
@@ -52,14 +83,14 @@ func TestRunAndPersistFailuresSkipsPersistenceOnGenericError(t *testing.T) {
 	}
 
 	original := executeMixTest
-	executeMixTest = func(files []string) (tui.TestRunOutcome, error) {
+	executeMixTest = func(run config.RunSettings, files []string) (tui.TestRunOutcome, error) {
 		return tui.TestRunOutcome{FailedFiles: []string{"test/new_failure_test.exs"}}, errors.New("boom")
 	}
 	t.Cleanup(func() {
 		executeMixTest = original
 	})
 
-	code := runAndPersistFailures(project, []string{"test/new_failure_test.exs"})
+	code := runAndPersistFailures(project, config.RunSettings{}, []string{"test/new_failure_test.exs"})
 	if code != 1 {
 		t.Fatalf("expected exit code 1 for generic error, got %d", code)
 	}
@@ -87,3 +118,80 @@ func TestFailuresForProjectReadsSavedFailures(t *testing.T) {
 		t.Fatalf("failuresForProject() = %v, want %v", got, want)
 	}
 }
+
+func TestPruneProjectStateDropsMissingFiles(t *testing.T) {
+	setupConfigEnv(t)
+	project := t.TempDir()
+
+	if err := config.SaveProjectSelections(project, []string{"test/a_test.exs", "test/deleted_test.exs"}); err != nil {
+		t.Fatalf("SaveProjectSelections returned error: %v", err)
+	}
+	if err := config.SaveProjectFailures(project, []string{"test/deleted_test.exs"}); err != nil {
+		t.Fatalf("SaveProjectFailures returned error: %v", err)
+	}
+
+	files := []testfile.TestFile{{Path: "test/a_test.exs"}}
+	if code := pruneProjectState(project, files); code != 0 {
+		t.Fatalf("expected exit code 0, got %d", code)
+	}
+
+	selections, _ := config.GetProjectSelections(project)
+	if want := []string{"test/a_test.exs"}; !reflect.DeepEqual(selections, want) {
+		t.Fatalf("unexpected selections after prune: got %v want %v", selections, want)
+	}
+	failures, _ := config.GetProjectFailures(project)
+	if len(failures) != 0 {
+		t.Fatalf("expected failures to be pruned, got %v", failures)
+	}
+}
+
+func TestReconcileSavedSkipsMissingButKeepsThemStored(t *testing.T) {
+	setupConfigEnv(t)
+	project := t.TempDir()
+	saved := []string{"test/a_test.exs", "test/deleted_test.exs"}
+
+	if err := config.SaveProjectSelections(project, saved); err != nil {
+		t.Fatalf("SaveProjectSelections returned error: %v", err)
+	}
+
+	files := []testfile.TestFile{{Path: "test/a_test.exs"}}
+	got := reconcileSaved(project, "selection", saved, files, config.SaveProjectSelections)
+	if want := []string{"test/a_test.exs"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("reconcileSaved() = %v, want %v", got, want)
+	}
+
+	stored, _ := config.GetProjectSelections(project)
+	if !reflect.DeepEqual(stored, saved) {
+		t.Fatalf("expected unresolved entries to remain until pruned, got %v", stored)
+	}
+}
+
+func TestResolveFileListUsesConfigUntilSaved(t *testing.T) {
+	setupConfigEnv(t)
+	p := project{
+		dir: t.TempDir(),
+		files: []testfile.TestFile{
+			{Path: "test/features/checkout_test.exs"},
+			{Path: "test/my_app/cart_test.exs"},
+		},
+	}
+
+	state, err := config.LoadProjectState(p.dir)
+	if err != nil {
+		t.Fatalf("LoadProjectState returned error: %v", err)
+	}
+	got := resolveFileList(p, "hidden file", []string{"test/features"}, state.Hidden, config.SaveProjectHidden)
+	if want := []string{"test/features/checkout_test.exs"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected config defaults before anything is saved, got %v", got)
+	}
+
+	if err := config.SaveProjectHidden(p.dir, nil); err != nil {
+		t.Fatalf("SaveProjectHidden returned error: %v", err)
+	}
+	if state, err = config.LoadProjectState(p.dir); err != nil {
+		t.Fatalf("LoadProjectState returned error: %v", err)
+	}
+	if got := resolveFileList(p, "hidden file", []string{"test/features"}, state.Hidden, config.SaveProjectHidden); len(got) != 0 {
+		t.Fatalf("expected the saved empty list to override the defaults, got %v", got)
+	}
+}
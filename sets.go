@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/samrobinsonsauce/eztest/internal/config"
+	"github.com/samrobinsonsauce/eztest/internal/testfile"
+	"github.com/samrobinsonsauce/eztest/internal/tui"
+)
+
+// savedSets returns the sets saved from the TUI, warning when they cannot
+// be read.
+func savedSets(projectDir string) map[string][]string {
+	saved, err := config.GetProjectSets(projectDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not load saved sets: %v\n", err)
+		return map[string][]string{}
+	}
+	return saved
+}
+
+// resolveNamedSets combines the saved sets with sets declared in config.
+// Saved sets shadow config sets of the same name. Config rules are expanded
+// against the discovered test files.
+func resolveNamedSets(projectDir string, saved map[string][]string, settings config.AppSettings, testFiles []testfile.TestFile) []tui.NamedSet {
+	sets := make([]tui.NamedSet, 0, len(saved)+len(settings.Sets))
+	for name, files := range saved {
+		res := testfile.ResolveSaved(projectDir, files, testFiles)
+		sets = append(sets, tui.NamedSet{Name: name, Files: res.Paths, Origin: "saved"})
+	}
+	for name, rule := range settings.Sets {
+		if _, ok := saved[name]; ok {
+			continue
+		}
+		files := testfile.SelectMatching(testFiles, rule.Paths, rule.Globs, rule.Tags)
+		sets = append(sets, tui.NamedSet{Name: name, Files: files, Origin: "config"})
+	}
+
+	sort.Slice(sets, func(i, j int) bool {
+		return sets[i].Name < sets[j].Name
+	})
+	return sets
+}
+
+func findNamedSet(sets []tui.NamedSet, name string) (tui.NamedSet, bool) {
+	for _, set := range sets {
+		if set.Name == name {
+			return set, true
+		}
+	}
+	return tui.NamedSet{}, false
+}
+
+func listNamedSets(sets []tui.NamedSet) int {
+	if len(sets) == 0 {
+		fmt.Println("No named sets. Save one from the TUI or define \"sets\" in .eztest.json.")
+		return 0
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, set := range sets {
+		fmt.Fprintf(tw, "%s\t%d file(s)\t%s\n", set.Name, len(set.Files), set.Origin)
+	}
+	tw.Flush()
+	return 0
+}